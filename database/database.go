@@ -2,54 +2,78 @@ package database
 
 import (
 	"database/sql"
+	"debtNote/phone"
+	"debtNote/textnorm"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-var DB *sql.DB
+// driverName is the sqlite3 driver with the app's SQL functions registered on every connection.
+const driverName = "sqlite3_debtnote"
 
-func InitDB() {
-	var err error
-	dbPath := "./database/debt.note.db"
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// Foreign keys are a per-connection setting, so every pooled connection needs it
+			if _, err := conn.Exec("PRAGMA foreign_keys = ON;", nil); err != nil {
+				return err
+			}
+			// fold(text): Unicode-aware lower-casing without diacritics, for case-insensitive
+			// search in any script. SQLite's own LIKE and lower() only handle ASCII.
+			if err := conn.RegisterFunc("fold", textnorm.Fold, true); err != nil {
+				return err
+			}
+			// skeleton(text): lossy Latin form of a name, to match it across Cyrillic and Latin spellings.
+			return conn.RegisterFunc("skeleton", textnorm.Skeleton, true)
+		},
+	})
+}
+
+// DefaultPath is where the shop's database is kept, relative to the working directory.
+const DefaultPath = "./database/debt.note.db"
 
+// Open opens the database at dbPath, creating it and bringing its tables up to date.
+func Open(dbPath string) *sql.DB {
 	// Ensure the directory exists
-	if _, err := os.Stat("./database"); os.IsNotExist(err) {
-		os.Mkdir("./database", os.ModePerm)
+	if err := os.MkdirAll(filepath.Dir(dbPath), os.ModePerm); err != nil {
+		log.Fatalf("Failed to create database directory: %v", err)
 	}
 
-	DB, err = sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(driverName, dbPath)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
 
-	if err = DB.Ping(); err != nil {
+	if err = db.Ping(); err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Enable foreign key support
-	_, err = DB.Exec("PRAGMA foreign_keys = ON;")
-	if err != nil {
-		log.Fatalf("Failed to enable foreign keys: %v", err)
-	}
-
 	log.Println("Database connection successful.")
-	createTables()
-	migrateTables()
+	createTables(db)
+	migrateTables(db)
+	createSearchIndex(db)
+	return db
 }
 
-func createTables() {
+func createTables(db *sql.DB) {
 	createClientsTableSQL := `CREATE TABLE IF NOT EXISTS clients (
 		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
 		"fullname" TEXT NOT NULL,
 		"phone" TEXT NOT NULL UNIQUE,
 		"address" TEXT,
 		"photo_data" TEXT,
+		"credit_limit" REAL,
+		"blocked" INTEGER NOT NULL DEFAULT 0,
+		"blocked_reason" TEXT,
+		"blocked_at" DATETIME,
 		"created_at" DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
 
-	if _, err := DB.Exec(createClientsTableSQL); err != nil {
+	if _, err := db.Exec(createClientsTableSQL); err != nil {
 		log.Fatalf("Failed to create clients table: %v", err)
 	}
 	log.Println("Clients table created or already exists.")
@@ -65,10 +89,12 @@ func createTables() {
 		"paid_at" DATETIME,
 		"deleted_at" DATETIME,
 		"delete_comment" TEXT,
+		"due_date" DATE,
+		"original_amount" REAL,
 		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
 	);`
 
-	if _, err := DB.Exec(createDebtsTableSQL); err != nil {
+	if _, err := db.Exec(createDebtsTableSQL); err != nil {
 		log.Fatalf("Failed to create debts table: %v", err)
 	}
 	log.Println("Debts table created or already exists.")
@@ -83,16 +109,188 @@ func createTables() {
 		FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE
 	);`
 
-	if _, err := DB.Exec(createDebtPaymentsTableSQL); err != nil {
+	if _, err := db.Exec(createDebtPaymentsTableSQL); err != nil {
 		log.Fatalf("Failed to create debt_payments table: %v", err)
 	}
 	log.Println("Debt payments table created or already exists.")
+
+	createSettingsTableSQL := `CREATE TABLE IF NOT EXISTS settings (
+		"key" TEXT NOT NULL PRIMARY KEY,
+		"value" TEXT NOT NULL,
+		"updated_at" DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := db.Exec(createSettingsTableSQL); err != nil {
+		log.Fatalf("Failed to create settings table: %v", err)
+	}
+	log.Println("Settings table created or already exists.")
+
+	createAuditLogTableSQL := `CREATE TABLE IF NOT EXISTS audit_log (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"entity" TEXT NOT NULL,
+		"entity_id" INTEGER NOT NULL,
+		"action" TEXT NOT NULL,
+		"details" TEXT,
+		"created_at" DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if _, err := db.Exec(createAuditLogTableSQL); err != nil {
+		log.Fatalf("Failed to create audit_log table: %v", err)
+	}
+	log.Println("Audit log table created or already exists.")
+
+	createDebtGuarantorsTableSQL := `CREATE TABLE IF NOT EXISTS debt_guarantors (
+		"debt_id" INTEGER NOT NULL,
+		"client_id" INTEGER NOT NULL,
+		"created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (debt_id, client_id),
+		FOREIGN KEY (debt_id) REFERENCES debts(id) ON DELETE CASCADE,
+		FOREIGN KEY (client_id) REFERENCES clients(id)
+	);`
+
+	if _, err := db.Exec(createDebtGuarantorsTableSQL); err != nil {
+		log.Fatalf("Failed to create debt_guarantors table: %v", err)
+	}
+	log.Println("Debt guarantors table created or already exists.")
+
+	createClientPhonesTableSQL := `CREATE TABLE IF NOT EXISTS client_phones (
+		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		"client_id" INTEGER NOT NULL,
+		"phone" TEXT NOT NULL UNIQUE,
+		"created_at" DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+	);`
+
+	if _, err := db.Exec(createClientPhonesTableSQL); err != nil {
+		log.Fatalf("Failed to create client_phones table: %v", err)
+	}
+	log.Println("Client phones table created or already exists.")
 }
 
-func migrateTables() {
+func migrateTables(db *sql.DB) {
 	// Try to add new columns if they don't exist.
 	// SQLite doesn't support "ADD COLUMN IF NOT EXISTS", so we just try and ignore error.
 
-	_, _ = DB.Exec(`ALTER TABLE debts ADD COLUMN deleted_at DATETIME;`)
-	_, _ = DB.Exec(`ALTER TABLE debts ADD COLUMN delete_comment TEXT;`)
+	_, _ = db.Exec(`ALTER TABLE debts ADD COLUMN deleted_at DATETIME;`)
+	_, _ = db.Exec(`ALTER TABLE debts ADD COLUMN delete_comment TEXT;`)
+	_, _ = db.Exec(`ALTER TABLE debts ADD COLUMN due_date DATE;`)
+	_, _ = db.Exec(`ALTER TABLE clients ADD COLUMN credit_limit REAL;`)
+	_, _ = db.Exec(`ALTER TABLE clients ADD COLUMN blocked INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = db.Exec(`ALTER TABLE clients ADD COLUMN blocked_reason TEXT;`)
+	_, _ = db.Exec(`ALTER TABLE clients ADD COLUMN blocked_at DATETIME;`)
+	_, _ = db.Exec(`ALTER TABLE debts ADD COLUMN original_amount REAL;`)
+
+	backfillOriginalAmounts(db)
+	backfillClientPhones(db)
+	migrateSettings(db)
+}
+
+// migrateSettings rewrites stored settings whose meaning changed. PRAGMA user_version records
+// which of these one-off changes the database has had, so each runs once.
+func migrateSettings(db *sql.DB) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		log.Fatalf("Failed to read database version: %v", err)
+	}
+
+	if version < 1 {
+		// A default credit limit of 0 used to mean no limit; now it means no credit and no limit is empty
+		if _, err := db.Exec("UPDATE settings SET value = '' WHERE key = 'default_credit_limit' AND CAST(value AS REAL) = 0"); err != nil {
+			log.Fatalf("Failed to migrate settings: %v", err)
+		}
+		if _, err := db.Exec("PRAGMA user_version = 1"); err != nil {
+			log.Fatalf("Failed to migrate settings: %v", err)
+		}
+	}
+}
+
+// backfillOriginalAmounts records the amount lent on debts created before original_amount existed.
+// Payments reduce debts.amount in place, so it is rebuilt from the first payment, which recorded
+// what it left owing. A debt closed by one payment keeps what was paid: whether that payment
+// handed over more than was owed was never recorded.
+func backfillOriginalAmounts(db *sql.DB) {
+	_, err := db.Exec(`UPDATE debts SET original_amount = COALESCE(
+		(SELECT p.paid_amount + p.remaining_amount FROM debt_payments p WHERE p.debt_id = debts.id ORDER BY p.id LIMIT 1),
+		amount
+	) WHERE original_amount IS NULL`)
+	if err != nil {
+		log.Fatalf("Failed to backfill original amounts: %v", err)
+	}
+}
+
+// backfillClientPhones copies the main phone of clients created before client_phones existed,
+// normalized, and normalizes their clients.phone to match. A number that normalizes to one another
+// client already has is copied as typed instead, so it is reported once and the client keeps it.
+// Numbers that don't normalize are kept as typed too, so they still match verbatim.
+func backfillClientPhones(db *sql.DB) {
+	rows, err := db.Query("SELECT id, phone FROM clients WHERE id NOT IN (SELECT client_id FROM client_phones)")
+	if err != nil {
+		log.Fatalf("Failed to read client phones: %v", err)
+	}
+
+	type clientPhone struct {
+		clientID int64
+		phone    string
+	}
+	var phones []clientPhone
+	for rows.Next() {
+		var p clientPhone
+		if err := rows.Scan(&p.clientID, &p.phone); err != nil {
+			log.Fatalf("Failed to read client phones: %v", err)
+		}
+		phones = append(phones, p)
+	}
+	rows.Close()
+
+	for _, p := range phones {
+		typed := strings.TrimSpace(p.phone)
+		normalized, err := phone.Normalize(typed)
+		if err != nil {
+			normalized = typed
+		}
+
+		res, err := db.Exec("INSERT OR IGNORE INTO client_phones(client_id, phone) VALUES(?, ?)", p.clientID, normalized)
+		if err != nil {
+			log.Fatalf("Failed to copy client phones: %v", err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			continue
+		}
+
+		log.Printf("Phone %s of client %d already belongs to another client, kept as typed: %q.", normalized, p.clientID, typed)
+		if _, err := db.Exec("INSERT OR IGNORE INTO client_phones(client_id, phone) VALUES(?, ?)", p.clientID, typed); err != nil {
+			log.Fatalf("Failed to copy client phones: %v", err)
+		}
+	}
+
+	// The main number is the one in clients.phone, so it has to be written the same way
+	rows, err = db.Query(`
+		SELECT c.id, c.phone FROM clients c
+		WHERE NOT EXISTS (SELECT 1 FROM client_phones cp WHERE cp.client_id = c.id AND cp.phone = c.phone)`)
+	if err != nil {
+		log.Fatalf("Failed to read client phones: %v", err)
+	}
+	phones = phones[:0]
+	for rows.Next() {
+		var p clientPhone
+		if err := rows.Scan(&p.clientID, &p.phone); err != nil {
+			log.Fatalf("Failed to read client phones: %v", err)
+		}
+		phones = append(phones, p)
+	}
+	rows.Close()
+
+	for _, p := range phones {
+		normalized, err := phone.Normalize(p.phone)
+		if err != nil {
+			continue
+		}
+		_, err = db.Exec(`
+			UPDATE OR IGNORE clients SET phone = ?
+			WHERE id = ? AND EXISTS (SELECT 1 FROM client_phones WHERE client_id = ? AND phone = ?)`,
+			normalized, p.clientID, p.clientID, normalized)
+		if err != nil {
+			log.Fatalf("Failed to normalize client phones: %v", err)
+		}
+	}
 }
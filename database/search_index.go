@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+)
+
+// searchIndexSQL creates the full-text tables and the triggers keeping them in sync.
+// Rows are keyed by the client or debt id. name_skeleton holds skeleton(fullname), so names
+// match across Cyrillic and Latin spellings; skeleton() is registered on every connection.
+var searchIndexSQL = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS clients_fts USING fts5(
+		fullname, name_skeleton, phone, address,
+		tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3'
+	);`,
+	`CREATE VIRTUAL TABLE IF NOT EXISTS debts_fts USING fts5(
+		fullname, name_skeleton, phone, address, comment,
+		tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3'
+	);`,
+
+	`CREATE TRIGGER IF NOT EXISTS clients_fts_insert AFTER INSERT ON clients BEGIN
+		INSERT INTO clients_fts(rowid, fullname, name_skeleton, phone, address)
+		VALUES (NEW.id, NEW.fullname, skeleton(NEW.fullname), NEW.phone, NEW.address);
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS clients_fts_update AFTER UPDATE OF fullname, phone, address ON clients BEGIN
+		DELETE FROM clients_fts WHERE rowid = OLD.id;
+		INSERT INTO clients_fts(rowid, fullname, name_skeleton, phone, address)
+		VALUES (NEW.id, NEW.fullname, skeleton(NEW.fullname), NEW.phone, NEW.address);
+		UPDATE debts_fts SET fullname = NEW.fullname, name_skeleton = skeleton(NEW.fullname), phone = NEW.phone, address = NEW.address
+		WHERE rowid IN (SELECT id FROM debts WHERE client_id = NEW.id);
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS clients_fts_delete AFTER DELETE ON clients BEGIN
+		DELETE FROM clients_fts WHERE rowid = OLD.id;
+	END;`,
+
+	`CREATE TRIGGER IF NOT EXISTS debts_fts_insert AFTER INSERT ON debts BEGIN
+		INSERT INTO debts_fts(rowid, fullname, name_skeleton, phone, address, comment)
+		SELECT NEW.id, c.fullname, skeleton(c.fullname), c.phone, c.address, NEW.comment FROM clients c WHERE c.id = NEW.client_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS debts_fts_update AFTER UPDATE OF client_id, comment ON debts BEGIN
+		DELETE FROM debts_fts WHERE rowid = OLD.id;
+		INSERT INTO debts_fts(rowid, fullname, name_skeleton, phone, address, comment)
+		SELECT NEW.id, c.fullname, skeleton(c.fullname), c.phone, c.address, NEW.comment FROM clients c WHERE c.id = NEW.client_id;
+	END;`,
+	`CREATE TRIGGER IF NOT EXISTS debts_fts_delete AFTER DELETE ON debts BEGIN
+		DELETE FROM debts_fts WHERE rowid = OLD.id;
+	END;`,
+}
+
+// rebuildSearchIndexSQL refills the full-text tables from scratch.
+var rebuildSearchIndexSQL = []string{
+	`DELETE FROM clients_fts;`,
+	`INSERT INTO clients_fts(rowid, fullname, name_skeleton, phone, address)
+		SELECT id, fullname, skeleton(fullname), phone, address FROM clients;`,
+	`DELETE FROM debts_fts;`,
+	`INSERT INTO debts_fts(rowid, fullname, name_skeleton, phone, address, comment)
+		SELECT d.id, c.fullname, skeleton(c.fullname), c.phone, c.address, d.comment FROM debts d JOIN clients c ON d.client_id = c.id;`,
+}
+
+// searchIndexTriggers lists the triggers created by searchIndexSQL.
+var searchIndexTriggers = []string{
+	"clients_fts_insert", "clients_fts_update", "clients_fts_delete",
+	"debts_fts_insert", "debts_fts_update", "debts_fts_delete",
+}
+
+// createSearchIndex sets up full-text search when the SQLite build supports FTS5.
+func createSearchIndex(db *sql.DB) {
+	// Checked up front: an existing fts5 table is not noticed by CREATE ... IF NOT EXISTS
+	var fts5 bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		log.Fatalf("Failed to check for FTS5: %v", err)
+	}
+	if !fts5 {
+		dropSearchIndexTriggers(db)
+		log.Println("Full-text search is off: this build has no FTS5 (build with -tags sqlite_fts5). " +
+			"Search uses LIKE and sorting by relevance lists the newest first.")
+		return
+	}
+
+	// Without the triggers (first run, or a build without FTS5 dropped them) the index is stale
+	var triggers int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE '%fts%'").Scan(&triggers)
+	if err != nil {
+		log.Fatalf("Failed to check search index: %v", err)
+	}
+	stale := triggers < len(searchIndexTriggers)
+
+	for _, stmt := range searchIndexSQL {
+		if _, err := db.Exec(stmt); err != nil {
+			log.Fatalf("Failed to create search index: %v", err)
+		}
+	}
+
+	if stale {
+		for _, stmt := range rebuildSearchIndexSQL {
+			if _, err := db.Exec(stmt); err != nil {
+				log.Fatalf("Failed to rebuild search index: %v", err)
+			}
+		}
+		log.Println("Search index rebuilt.")
+	}
+
+	log.Println("Full-text search enabled.")
+}
+
+// FullTextSearch reports whether db has the full-text tables, which Open keeps in place when the
+// SQLite build has FTS5. Without them, searches fall back to LIKE. FTS5 needs the sqlite_fts5 build tag:
+//
+//	go build -tags sqlite_fts5
+func FullTextSearch(db *sql.DB) bool {
+	var fts5 bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil || !fts5 {
+		return false
+	}
+	var tables int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('clients_fts', 'debts_fts')").Scan(&tables)
+	return err == nil && tables == 2
+}
+
+// dropSearchIndexTriggers removes the sync triggers left by an FTS5 build: without the module
+// they would make every write to clients and debts fail.
+func dropSearchIndexTriggers(db *sql.DB) {
+	for _, name := range searchIndexTriggers {
+		if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+			log.Fatalf("Failed to drop search trigger %s: %v", name, err)
+		}
+	}
+}
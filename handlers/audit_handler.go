@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"debtNote/models"
+	"encoding/json"
+	"net/http"
+)
+
+// GetAuditEntriesHandler returns the audit log of one client or debt ("entity" and "entity_id").
+func (s *Server) GetAuditEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	entity := r.URL.Query().Get("entity")
+	if entity != models.AuditEntityClient && entity != models.AuditEntityDebt {
+		invalidParameter(w, r, "entity", "invalid_choice", "entity", entity, "client, debt")
+		return
+	}
+
+	entityID, err := parseID(r, "entity_id")
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	entries, err := s.Audit.GetAuditEntries(r.Context(), entity, entityID)
+	if err != nil {
+		internalError(w, r, "get audit log", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -1,66 +1,325 @@
 package handlers
 
 import (
+	"database/sql"
 	"debtNote/repository"
 	"encoding/json"
 	"net/http"
-	"strconv"
 )
 
 // PaginatedResponse is defined in debt_handler.go, but we can redefine or reuse.
 // Since they are in the same package, we can reuse it if it's exported, or define a local struct.
 // Let's reuse the one from debt_handler.go since they are in the same package 'handlers'.
 
-func SearchClientsHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
+func (s *Server) SearchClientsHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+		invalidParameter(w, r, "q", "param_required", "q")
 		return
 	}
 
-	clients, err := repository.SearchClients(query)
+	clients, err := s.Clients.SearchClients(r.Context(), query)
 	if err != nil {
-		http.Error(w, "Failed to search clients: "+err.Error(), http.StatusInternalServerError)
+		internalError(w, r, "search clients", err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(clients); err != nil {
-		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		internalError(w, r, "encode response", err)
 	}
 }
 
-func GetClientsHandler(w http.ResponseWriter, r *http.Request) {
-	searchQuery := r.URL.Query().Get("search")
-	dateQuery := r.URL.Query().Get("date")
+// GetClientsHandler lists clients with their balances. Besides search it filters by from/to (date added,
+// "date" for a single day), has_active_debt, min_balance/max_balance (total of active debts) and reputation,
+// and sorts by sort_by: balance_desc, balance_asc, activity_desc or activity_asc.
+func (s *Server) GetClientsHandler(w http.ResponseWriter, r *http.Request) {
+	filter := repository.ClientFilter{
+		Search:     r.URL.Query().Get("search"),
+		Reputation: r.URL.Query().Get("reputation"),
+	}
+	if err := s.parseClientFilter(r, &filter); err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	page := parsePage(r, 200)
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	clients, result, err := s.Clients.GetClients(r.Context(), filter, r.URL.Query().Get("sort_by"), page)
+	if err == repository.ErrInvalidCursor {
+		message := tr(r, "invalid_cursor")
+		writeError(w, http.StatusBadRequest, CodeInvalidCursor, message, FieldError{Field: "cursor", Message: message})
+		return
 	}
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit <= 0 {
-		limit = 200 // Default limit
+	if err != nil {
+		internalError(w, r, "fetch clients", err)
+		return
+	}
+
+	response := pageResponse(clients, page, result)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		internalError(w, r, "encode response", err)
 	}
+}
 
-	clients, total, err := repository.GetClients(searchQuery, dateQuery, page, limit)
+// GetClientHandler returns one client with their numbers, balances, reputation, credit and audit summary.
+func (s *Server) GetClientHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, err := parseID(r, "id")
 	if err != nil {
-		http.Error(w, "Failed to fetch clients: "+err.Error(), http.StatusInternalServerError)
+		writeParamError(w, r, err)
 		return
 	}
 
-	response := PaginatedResponse{
-		Data:  clients,
-		Total: total,
+	detail, err := s.Clients.GetClientDetail(r.Context(), clientID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, CodeClientNotFound, tr(r, "client_not_found"))
+		return
+	} else if err != nil {
+		internalError(w, r, "get client", err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(detail)
+}
+
+// parseClientFilter fills the date, balance, active-debt and reputation filters from the query,
+// rejecting malformed values.
+func (s *Server) parseClientFilter(r *http.Request, filter *repository.ClientFilter) error {
+	switch filter.Reputation {
+	case "", "good", "bad", "untrusted", "none":
+	default:
+		return invalidParam("reputation", "invalid_choice", "reputation", filter.Reputation, "good, bad, untrusted, none")
+	}
+
+	loc, err := s.reportLocation(r)
+	if err != nil {
+		return err
+	}
+	dayFrom, dayTo, err := parseDay(r, loc)
+	if err != nil {
+		return err
+	}
+	if filter.CreatedFrom, filter.CreatedTo, err = parseDateRange(r, loc); err != nil {
+		return err
+	}
+	if filter.CreatedFrom.IsZero() && filter.CreatedTo.IsZero() {
+		filter.CreatedFrom, filter.CreatedTo = dayFrom, dayTo
+	}
+
+	if filter.HasActiveDebt, err = parseBool(r, "has_active_debt"); err != nil {
+		return err
+	}
+	if filter.MinBalance, err = parseAmount(r, "min_balance"); err != nil {
+		return err
+	}
+	if filter.MaxBalance, err = parseAmount(r, "max_balance"); err != nil {
+		return err
 	}
+	if filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return invalidParam("min_balance", "min_above_max", "min_balance", "max_balance")
+	}
+	return nil
+}
+
+// SetCreditLimitHandler sets or clears (credit_limit: null) a client's own credit limit.
+// It needs the owner's PIN.
+func (s *Server) SetCreditLimitHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID    int64    `json:"client_id"`
+		CreditLimit *float64 `json:"credit_limit"`
+		OwnerPin    string   `json:"owner_pin"`
+	}
+
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
+		return
+	}
+	if err := pathID(r, &payload.ClientID); err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	if err := s.ClientService.SetCreditLimit(r.Context(), payload.ClientID, payload.CreditLimit, payload.OwnerPin); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "credit_limit_saved")})
+}
+
+// SetClientBlockedHandler puts a client on the stop-credit list (a reason is required) or takes them
+// off it (the owner's PIN is required).
+func (s *Server) SetClientBlockedHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID int64  `json:"client_id"`
+		Blocked  bool   `json:"blocked"`
+		Reason   string `json:"reason"`
+		OwnerPin string `json:"owner_pin"`
+	}
+
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
+		return
+	}
+	if err := pathID(r, &payload.ClientID); err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	if err := s.ClientService.SetBlocked(r.Context(), payload.ClientID, payload.Blocked, payload.Reason, payload.OwnerPin); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "client_updated")})
+}
+
+// DeleteClientHandler removes a client. Mode "delete" removes the client entirely and is refused
+// while they have debts; mode "anonymize" erases their personal data and photo but keeps the debts.
+func (s *Server) DeleteClientHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID int64  `json:"client_id"`
+		Mode     string `json:"mode"` // 'delete' or 'anonymize'
+	}
+
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
+		return
+	}
+	if err := pathID(r, &payload.ClientID); err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	if err := s.ClientService.Remove(r.Context(), payload.ClientID, payload.Mode); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "client_removed")})
+}
+
+// GetGuarantorStatementHandler returns the debts a client vouched for as a guarantor.
+func (s *Server) GetGuarantorStatementHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, err := parseID(r, "client_id")
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	statement, err := s.Clients.GetGuarantorStatement(r.Context(), clientID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, CodeClientNotFound, tr(r, "client_not_found"))
+		return
+	} else if err != nil {
+		internalError(w, r, "get guarantees", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statement)
+}
+
+// GetClientPhonesHandler returns all phone numbers of a client.
+func (s *Server) GetClientPhonesHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, err := parseID(r, "client_id")
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	phones, err := s.Clients.GetClientPhones(r.Context(), clientID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, CodeClientNotFound, tr(r, "client_not_found"))
+		return
+	} else if err != nil {
+		internalError(w, r, "get phones", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(phones)
+}
+
+// AddClientPhoneHandler adds another phone number to a client.
+func (s *Server) AddClientPhoneHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID int64  `json:"client_id"`
+		Phone    string `json:"phone"`
+	}
+
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
+		return
+	}
+	if err := pathID(r, &payload.ClientID); err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	if err := s.Clients.AddClientPhone(r.Context(), payload.ClientID, payload.Phone); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "phone_added")})
+}
+
+// DeleteClientPhoneHandler removes one of a client's extra phone numbers.
+func (s *Server) DeleteClientPhoneHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ClientID int64  `json:"client_id"`
+		Phone    string `json:"phone"`
+	}
+
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
+		return
+	}
+	if err := pathID(r, &payload.ClientID); err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+	if phone := r.PathValue("phone"); phone != "" {
+		payload.Phone = phone
+	}
+
+	err := s.Clients.RemoveClientPhone(r.Context(), payload.ClientID, payload.Phone)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, CodePhoneNotFound, tr(r, "phone_not_found"))
+		return
+	} else if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "phone_deleted")})
+}
+
+// GetDuplicateClientsHandler returns existing clients that look like the one about to be added,
+// by a similar name or a phone number a digit or two away.
+func (s *Server) GetDuplicateClientsHandler(w http.ResponseWriter, r *http.Request) {
+	fullname := r.URL.Query().Get("fullname")
+	phoneQuery := r.URL.Query().Get("phone")
+	if fullname == "" && phoneQuery == "" {
+		invalidParameter(w, r, "fullname", "param_required_either", "fullname", "phone")
+		return
+	}
+
+	candidates, err := s.Clients.FindDuplicateCandidates(r.Context(), fullname, phoneQuery)
+	if err != nil {
+		internalError(w, r, "find duplicates", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(candidates)
 }
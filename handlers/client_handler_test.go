@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"debtNote/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetCreditLimitHandler(t *testing.T) {
+	t.Setenv("DEBTNOTE_OWNER_PIN", "1234")
+	f := newFakeServer()
+	f.clients.clients[1] = models.Client{ID: 1}
+
+	w := serve(f.SetCreditLimitHandler, "PUT /api/clients/{id}/credit-limit", http.MethodPut, "/api/clients/1/credit-limit", `{"credit_limit": 500}`)
+	expectError(t, w, http.StatusForbidden, CodeOwnerPinRequired)
+
+	w = serve(f.SetCreditLimitHandler, "PUT /api/clients/{id}/credit-limit", http.MethodPut, "/api/clients/1/credit-limit", `{"credit_limit": -5, "owner_pin": "1234"}`)
+	expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+
+	w = serve(f.SetCreditLimitHandler, "PUT /api/clients/{id}/credit-limit", http.MethodPut, "/api/clients/2/credit-limit", `{"credit_limit": 5, "owner_pin": "1234"}`)
+	expectError(t, w, http.StatusNotFound, CodeClientNotFound)
+
+	w = serve(f.SetCreditLimitHandler, "PUT /api/clients/{id}/credit-limit", http.MethodPut, "/api/clients/1/credit-limit", `{"credit_limit": 0, "owner_pin": "1234"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d; want 200", w.Code)
+	}
+	if limit := f.clients.limits[1]; limit == nil || *limit != 0 {
+		t.Errorf("limit = %v; want 0 (no credit)", limit)
+	}
+	want := models.AuditEntry{Entity: models.AuditEntityClient, EntityID: 1, Action: "credit_limit_changed", Details: "0"}
+	if len(f.audit.entries) != 1 || f.audit.entries[0] != want {
+		t.Errorf("audit = %+v; want %+v", f.audit.entries, want)
+	}
+}
+
+func TestSetClientBlockedHandler(t *testing.T) {
+	t.Setenv("DEBTNOTE_OWNER_PIN", "1234")
+	f := newFakeServer()
+	f.clients.clients[1] = models.Client{ID: 1}
+	block := func(target, body string) *httptest.ResponseRecorder {
+		return serve(f.SetClientBlockedHandler, "PUT /api/clients/{id}/block", http.MethodPut, target, body)
+	}
+
+	w := block("/api/clients/1/block", `{"blocked": true, "reason": "  "}`)
+	expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+	w = block("/api/clients/2/block", `{"blocked": true, "reason": "never pays"}`)
+	expectError(t, w, http.StatusNotFound, CodeClientNotFound)
+
+	// Anyone may block; only the owner may unblock
+	if w = block("/api/clients/1/block", `{"blocked": true, "reason": "never pays"}`); w.Code != http.StatusOK {
+		t.Fatalf("block: got %d; want 200", w.Code)
+	}
+	w = block("/api/clients/1/block", `{"blocked": false, "owner_pin": "0000"}`)
+	expectError(t, w, http.StatusForbidden, CodeOwnerPinRequired)
+	if client := f.clients.clients[1]; !client.Blocked || client.BlockedReason != "never pays" {
+		t.Fatalf("client = %+v; want blocked for never paying", client)
+	}
+	if w = block("/api/clients/1/block", `{"blocked": false, "owner_pin": "1234"}`); w.Code != http.StatusOK {
+		t.Fatalf("unblock: got %d; want 200", w.Code)
+	}
+	if f.clients.clients[1].Blocked {
+		t.Error("client still blocked")
+	}
+}
@@ -1,29 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
 	"debtNote/models"
 	"debtNote/repository"
-	"encoding/base64"
+	"debtNote/service"
 	"encoding/json"
-	"fmt"
-	"math/rand"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
-	"unicode"
 )
 
 // AddDebtRequest represents the incoming request for adding a debt.
 type AddDebtRequest struct {
-	Fullname  string  `json:"fullname"`
-	Phone     string  `json:"phone"`
-	Address   string  `json:"address"`
-	PhotoData string  `json:"photo_data"`
-	Amount    float64 `json:"amount"`
-	Comment   string  `json:"comment"`
+	Fullname   string  `json:"fullname"`
+	Phone      string  `json:"phone"`
+	Address    string  `json:"address"`
+	PhotoData  string  `json:"photo_data"`
+	Amount     float64 `json:"amount"`
+	Comment    string  `json:"comment"`
+	DueDate    string  `json:"due_date"`      // Optional, YYYY-MM-DD
+	Guarantors []int64 `json:"guarantor_ids"` // Optional clients vouching for the debt
+	Override   bool    `json:"override"`      // Owner only: add the debt even if credit checks refuse it
+	OwnerPin   string  `json:"owner_pin"`     // Required with Override
 }
 
 // PaginatedResponse is a generic wrapper for paginated data.
@@ -32,101 +31,149 @@ type PaginatedResponse struct {
 	Total int         `json:"total"`
 }
 
-func GetDebtsHandler(w http.ResponseWriter, r *http.Request) {
-	searchQuery := r.URL.Query().Get("search")
-	dateQuery := r.URL.Query().Get("date")
-	status := r.URL.Query().Get("status")
+// CursorResponse wraps a page fetched by cursor. NextCursor is empty on the last page;
+// Total is only present when it was asked for.
+type CursorResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor"`
+	Total      *int        `json:"total,omitempty"`
+}
+
+// GetDebtsHandler lists debts. Besides search, status and client_id it filters by
+// from/to (created date, or deletion date for deleted debts), created_from/created_to,
+// paid_from/paid_to, deleted_from/deleted_to, min_amount/max_amount and rating.
+func (s *Server) GetDebtsHandler(w http.ResponseWriter, r *http.Request) {
+	filter := repository.DebtFilter{
+		Search: r.URL.Query().Get("search"),
+		Status: r.URL.Query().Get("status"),
+		Rating: r.URL.Query().Get("rating"),
+	}
 	sortBy := r.URL.Query().Get("sort_by")
 
-	clientID, _ := strconv.ParseInt(r.URL.Query().Get("client_id"), 10, 64)
+	filter.ClientID, _ = strconv.ParseInt(r.URL.Query().Get("client_id"), 10, 64)
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
-	}
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit <= 0 {
-		limit = 20 // Default limit
+	if err := s.parseDebtFilter(r, &filter); err != nil {
+		writeParamError(w, r, err)
+		return
 	}
 
-	debts, total, err := repository.GetDebts(searchQuery, dateQuery, status, clientID, sortBy, page, limit)
+	page := parsePage(r, 20)
+
+	debts, result, err := s.Debts.GetDebts(r.Context(), filter, sortBy, page)
+	if err == repository.ErrInvalidCursor {
+		message := tr(r, "invalid_cursor")
+		writeError(w, http.StatusBadRequest, CodeInvalidCursor, message, FieldError{Field: "cursor", Message: message})
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to fetch debts: "+err.Error(), http.StatusInternalServerError)
+		internalError(w, r, "fetch debts", err)
 		return
 	}
 
-	response := PaginatedResponse{
-		Data:  debts,
-		Total: total,
-	}
+	response := pageResponse(debts, page, result)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		internalError(w, r, "encode response", err)
 	}
 }
 
-func AddDebtHandler(w http.ResponseWriter, r *http.Request) {
-	var req AddDebtRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-		return
+// parseDebtFilter fills the date, amount and rating filters from the query, rejecting malformed values.
+func (s *Server) parseDebtFilter(r *http.Request, filter *repository.DebtFilter) error {
+	switch models.DebtRating(filter.Rating) {
+	case "", models.RatingGood, models.RatingBad, models.RatingUntrusted:
+	default:
+		return invalidParam("rating", "invalid_choice", "rating", filter.Rating, "good, bad, untrusted")
+	}
+
+	loc, err := s.reportLocation(r)
+	if err != nil {
+		return err
 	}
 
-	// Handle Photo Saving
-	var photoPath string
-	if req.PhotoData != "" {
-		// Check if it's an existing file path (starts with /uploads/)
-		if strings.HasPrefix(req.PhotoData, "/uploads/") {
-			photoPath = req.PhotoData
-		} else {
-			// It's a new Base64 image, save it
-			var err error
-			photoPath, err = saveImage(req.PhotoData, req.Fullname)
-			if err != nil {
-				http.Error(w, "Failed to save image: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
+	// "date" and "from"/"to" apply to the date the list is about: deletion for deleted debts, else creation
+	dayFrom, dayTo, err := parseDay(r, loc)
+	if err != nil {
+		return err
+	}
+	from, to, err := parseDateRange(r, loc)
+	if err != nil {
+		return err
+	}
+	if from.IsZero() && to.IsZero() {
+		from, to = dayFrom, dayTo
+	}
+
+	if filter.CreatedFrom, filter.CreatedTo, err = parseNamedDateRange(r, "created_from", "created_to", loc); err != nil {
+		return err
+	}
+	if filter.PaidFrom, filter.PaidTo, err = parseNamedDateRange(r, "paid_from", "paid_to", loc); err != nil {
+		return err
+	}
+	if filter.DeletedFrom, filter.DeletedTo, err = parseNamedDateRange(r, "deleted_from", "deleted_to", loc); err != nil {
+		return err
+	}
+
+	if filter.Status == string(models.StatusDeleted) {
+		if filter.DeletedFrom.IsZero() && filter.DeletedTo.IsZero() {
+			filter.DeletedFrom, filter.DeletedTo = from, to
 		}
+	} else if filter.CreatedFrom.IsZero() && filter.CreatedTo.IsZero() {
+		filter.CreatedFrom, filter.CreatedTo = from, to
 	}
 
-	// 1. Find or create the client
-	client := models.Client{
-		Fullname:  req.Fullname,
-		Phone:     req.Phone,
-		Address:   req.Address,
-		PhotoData: photoPath, // Save the path
+	if filter.MinAmount, err = parseAmount(r, "min_amount"); err != nil {
+		return err
 	}
+	if filter.MaxAmount, err = parseAmount(r, "max_amount"); err != nil {
+		return err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return invalidParam("min_amount", "min_above_max", "min_amount", "max_amount")
+	}
+	return nil
+}
 
-	clientID, err := repository.FindOrCreateClient(client)
-	if err != nil {
-		http.Error(w, "Failed to process client: "+err.Error(), http.StatusInternalServerError)
+func (s *Server) AddDebtHandler(w http.ResponseWriter, r *http.Request) {
+	var req AddDebtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		invalidBody(w, r, err)
 		return
 	}
 
-	// 2. Add the debt for the client
-	debt := models.Debt{
-		ClientID: clientID,
-		Amount:   req.Amount,
-		Comment:  req.Comment,
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		t, err := time.Parse(dateLayout, req.DueDate)
+		if err != nil {
+			invalidField(w, r, "due_date", "due_date_invalid")
+			return
+		}
+		dueDate = &t
 	}
-	_, err = repository.AddDebt(debt)
+
+	_, err := s.DebtService.Add(r.Context(), service.NewDebt{
+		Fullname:   req.Fullname,
+		Phone:      req.Phone,
+		Address:    req.Address,
+		Photo:      req.PhotoData,
+		Amount:     req.Amount,
+		Comment:    req.Comment,
+		DueDate:    dueDate,
+		Guarantors: req.Guarantors,
+		Override:   req.Override,
+		OwnerPin:   req.OwnerPin,
+	})
 	if err != nil {
-		http.Error(w, "Failed to add debt: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Debt added successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "debt_added")})
 }
 
 // MakePaymentHandler handles partial or full payments.
-func MakePaymentHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
+func (s *Server) MakePaymentHandler(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		DebtID     int64             `json:"debt_id"`
 		PaidAmount float64           `json:"paid_amount"`
@@ -134,38 +181,62 @@ func MakePaymentHandler(w http.ResponseWriter, r *http.Request) {
 		Rating     models.DebtRating `json:"rating"` // Used only if debt is fully paid
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
 		return
 	}
-
-	if payload.Comment == "" {
-		http.Error(w, "Комментарий милдеттүү", http.StatusBadRequest)
+	if err := pathID(r, &payload.DebtID); err != nil {
+		writeParamError(w, r, err)
 		return
 	}
 
-	err := repository.MakePayment(payload.DebtID, payload.PaidAmount, payload.Comment, payload.Rating)
+	_, err := s.DebtService.Pay(r.Context(), service.Payment{
+		DebtID:  payload.DebtID,
+		Amount:  payload.PaidAmount,
+		Comment: payload.Comment,
+		Rating:  payload.Rating,
+	})
 	if err != nil {
-		http.Error(w, "Failed to make payment: "+err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Payment made successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "payment_made")})
+}
+
+// GetDebtHandler returns one debt with its client, payments, repayment schedule and audit summary.
+func (s *Server) GetDebtHandler(w http.ResponseWriter, r *http.Request) {
+	debtID, err := parseID(r, "id")
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	detail, err := s.Debts.GetDebtDetail(r.Context(), debtID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, CodeDebtNotFound, tr(r, "debt_not_found"))
+		return
+	} else if err != nil {
+		internalError(w, r, "get debt", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
 }
 
 // GetDebtPaymentsHandler retrieves payment history for a debt.
-func GetDebtPaymentsHandler(w http.ResponseWriter, r *http.Request) {
-	debtIDStr := r.URL.Query().Get("debt_id")
-	debtID, err := strconv.ParseInt(debtIDStr, 10, 64)
+func (s *Server) GetDebtPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	debtID, err := parseID(r, "debt_id")
 	if err != nil {
-		http.Error(w, "Invalid debt_id", http.StatusBadRequest)
+		writeParamError(w, r, err)
 		return
 	}
 
-	payments, err := repository.GetDebtPayments(debtID)
+	payments, err := s.Debts.GetDebtPayments(r.Context(), debtID)
 	if err != nil {
-		http.Error(w, "Failed to get payments: "+err.Error(), http.StatusInternalServerError)
+		internalError(w, r, "get payments", err)
 		return
 	}
 
@@ -174,86 +245,50 @@ func GetDebtPaymentsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteDebtHandler handles soft deletion of a debt.
-func DeleteDebtHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
+func (s *Server) DeleteDebtHandler(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		DebtID  int64  `json:"debt_id"`
 		Comment string `json:"comment"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
 		return
 	}
-
-	if payload.Comment == "" {
-		http.Error(w, "Өчүрүү себеби (комментарий) милдеттүү", http.StatusBadRequest)
+	if err := pathID(r, &payload.DebtID); err != nil {
+		writeParamError(w, r, err)
 		return
 	}
 
-	err := repository.DeleteDebt(payload.DebtID, payload.Comment)
-	if err != nil {
-		http.Error(w, "Failed to delete debt: "+err.Error(), http.StatusInternalServerError)
+	if err := s.DebtService.Delete(r.Context(), payload.DebtID, payload.Comment); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Debt deleted successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "debt_deleted")})
 }
 
-// saveImage decodes base64 image and saves it to disk
-func saveImage(base64Data, fullname string) (string, error) {
-	// Remove the data URL prefix (e.g., "data:image/jpeg;base64,")
-	parts := strings.Split(base64Data, ",")
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid base64 data")
-	}
-
-	data, err := base64.StdEncoding.DecodeString(parts[1])
-	if err != nil {
-		return "", err
+// RestoreDebtHandler takes a deleted debt out of the trash.
+func (s *Server) RestoreDebtHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		DebtID int64 `json:"debt_id"`
 	}
 
-	// Create directory for current month: uploads/YYYY-MM
-	currentMonth := time.Now().Format("2006-01")
-	dirPath := filepath.Join("uploads", currentMonth)
-	if err := os.MkdirAll(dirPath, 0755); err != nil {
-		return "", err
+	if err := decodeBody(r, &payload); err != nil {
+		invalidBody(w, r, err)
+		return
 	}
-
-	// Sanitize fullname for filename (Allow letters, numbers, spaces, underscores, hyphens)
-	// We use unicode.IsLetter to support Cyrillic and other scripts
-	safeName := strings.Map(func(r rune) rune {
-		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' || r == '-' {
-			return r
-		}
-		if unicode.IsSpace(r) {
-			return '_'
-		}
-		return -1 // Drop other characters
-	}, fullname)
-
-	// Fallback if name becomes empty
-	if safeName == "" {
-		safeName = "unknown"
+	if err := pathID(r, &payload.DebtID); err != nil {
+		writeParamError(w, r, err)
+		return
 	}
 
-	// Generate filename: Name_Date_Random.jpg
-	dateStr := time.Now().Format("2006-01-02")
-	randNum := rand.Intn(100000)
-	filename := fmt.Sprintf("%s_%s_%d.jpg", safeName, dateStr, randNum)
-	filePath := filepath.Join(dirPath, filename)
-
-	// Write file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return "", err
+	if err := s.DebtService.Restore(r.Context(), payload.DebtID); err != nil {
+		writeServiceError(w, r, err)
+		return
 	}
 
-	// Return the path relative to the server root, using forward slashes for URL compatibility
-	// e.g., /uploads/2023-10/John_Doe_2023-10-27_123.jpg
-	return "/" + filepath.ToSlash(filePath), nil
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "debt_restored")})
 }
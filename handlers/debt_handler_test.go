@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"debtNote/models"
+	"net/http"
+	"testing"
+)
+
+func TestGetDebtHandler(t *testing.T) {
+	f := newFakeServer()
+	f.debts.debts[7] = models.Debt{ID: 7, ClientID: 1, Amount: 250, Status: models.StatusActive}
+
+	w := serve(f.GetDebtHandler, "GET /api/debts/{id}", http.MethodGet, "/api/debts/7", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("existing debt: got %d; want 200", w.Code)
+	}
+
+	w = serve(f.GetDebtHandler, "GET /api/debts/{id}", http.MethodGet, "/api/debts/8", "")
+	expectError(t, w, http.StatusNotFound, CodeDebtNotFound)
+
+	for _, id := range []string{"abc", "0", "-1"} {
+		w = serve(f.GetDebtHandler, "GET /api/debts/{id}", http.MethodGet, "/api/debts/"+id, "")
+		expectError(t, w, http.StatusBadRequest, CodeInvalidParameter)
+	}
+}
+
+func TestMakePaymentHandler(t *testing.T) {
+	f := newFakeServer()
+	f.debts.debts[1] = models.Debt{ID: 1, Amount: 100, Status: models.StatusActive}
+
+	w := serve(f.MakePaymentHandler, "POST /api/debts/{id}/payments", http.MethodPost, "/api/debts/1/payments", `{"paid_amount": 30, "comment": "cash"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("partial payment: got %d; want 200", w.Code)
+	}
+	if debt := f.debts.debts[1]; debt.Amount != 70 || debt.Status != models.StatusActive {
+		t.Errorf("after paying 30 of 100: %+v", debt)
+	}
+
+	// Paying more than is owed closes the debt and records what was handed over
+	w = serve(f.MakePaymentHandler, "POST /api/debts/{id}/payments", http.MethodPost, "/api/debts/1/payments", `{"paid_amount": 100, "comment": "rest", "rating": "good"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("closing payment: got %d; want 200", w.Code)
+	}
+	if debt := f.debts.debts[1]; debt.Status != models.StatusPaid || debt.Rating != models.RatingGood {
+		t.Errorf("after paying the rest: %+v", debt)
+	}
+	if last := f.debts.payments[len(f.debts.payments)-1]; last.PaidAmount != 100 || last.RemainingAmount != 0 {
+		t.Errorf("closing payment recorded as %+v; want 100 paid, 0 remaining", last)
+	}
+
+	w = serve(f.MakePaymentHandler, "POST /api/debts/{id}/payments", http.MethodPost, "/api/debts/1/payments", `{"paid_amount": 10, "comment": "again"}`)
+	expectError(t, w, http.StatusConflict, CodeDebtNotActive)
+
+	w = serve(f.MakePaymentHandler, "POST /api/debts/{id}/payments", http.MethodPost, "/api/debts/2/payments", `{"paid_amount": 10, "comment": "x"}`)
+	expectError(t, w, http.StatusNotFound, CodeDebtNotFound)
+}
+
+func TestMakePaymentHandlerInvalid(t *testing.T) {
+	f := newFakeServer()
+	f.debts.debts[1] = models.Debt{ID: 1, Amount: 100, Status: models.StatusActive}
+
+	tests := []struct {
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{`{"paid_amount": 0, "comment": "x"}`, http.StatusUnprocessableEntity, CodeValidationFailed, "paid_amount"},
+		{`{"paid_amount": 10}`, http.StatusUnprocessableEntity, CodeValidationFailed, "comment"},
+		{`{"paid_amount": 10, "comment": "x", "rating": "great"}`, http.StatusUnprocessableEntity, CodeValidationFailed, "rating"},
+		{`{"paid_amount": "ten", "comment": "x"}`, http.StatusBadRequest, CodeInvalidBody, "paid_amount"},
+		{`{`, http.StatusBadRequest, CodeInvalidBody, ""},
+	}
+	for _, tt := range tests {
+		w := serve(f.MakePaymentHandler, "POST /api/debts/{id}/payments", http.MethodPost, "/api/debts/1/payments", tt.body)
+		body := expectError(t, w, tt.status, tt.code)
+		if tt.field != "" && (len(body.Details) != 1 || body.Details[0].Field != tt.field) {
+			t.Errorf("%s: details = %+v; want field %q", tt.body, body.Details, tt.field)
+		}
+	}
+	if len(f.debts.payments) != 0 {
+		t.Errorf("invalid payments were saved: %+v", f.debts.payments)
+	}
+}
+
+func TestDeleteDebtHandler(t *testing.T) {
+	f := newFakeServer()
+	f.debts.debts[1] = models.Debt{ID: 1, Amount: 100, Status: models.StatusActive}
+
+	w := serve(f.DeleteDebtHandler, "DELETE /api/debts/{id}", http.MethodDelete, "/api/debts/1", `{}`)
+	expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+
+	w = serve(f.DeleteDebtHandler, "DELETE /api/debts/{id}", http.MethodDelete, "/api/debts/1", `{"comment": "typed twice"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d; want 200", w.Code)
+	}
+	if debt := f.debts.debts[1]; debt.Status != models.StatusDeleted {
+		t.Errorf("debt not deleted: %+v", debt)
+	}
+	want := models.AuditEntry{Entity: models.AuditEntityDebt, EntityID: 1, Action: "deleted", Details: "typed twice"}
+	if len(f.audit.entries) != 1 || f.audit.entries[0] != want {
+		t.Errorf("audit = %+v; want %+v", f.audit.entries, want)
+	}
+}
+
+func TestAddDebtHandlerCredit(t *testing.T) {
+	t.Setenv("DEBTNOTE_OWNER_PIN", "1234")
+	f := newFakeServer()
+	f.clients.clients[1] = models.Client{ID: 1, Fullname: "Асан", Phone: "0555123456"}
+	limit := 100.0
+	f.clients.credit = models.CreditCheck{Reason: models.CreditReasonLimitExceeded, Limit: &limit, Outstanding: 80}
+
+	add := `{"fullname": "Асан", "phone": "0555123456", "amount": 50`
+	w := serve(f.AddDebtHandler, "POST /api/debts", http.MethodPost, "/api/debts", add+`}`)
+	body := expectError(t, w, http.StatusUnprocessableEntity, models.CreditReasonLimitExceeded)
+	if body.Credit == nil || body.Credit.Requested != 50 {
+		t.Errorf("credit = %+v; want the refused check", body.Credit)
+	}
+
+	w = serve(f.AddDebtHandler, "POST /api/debts", http.MethodPost, "/api/debts", add+`, "override": true, "owner_pin": "4321"}`)
+	expectError(t, w, http.StatusForbidden, CodeOwnerPinRequired)
+	if len(f.debts.added) != 0 {
+		t.Fatalf("refused debts were saved: %+v", f.debts.added)
+	}
+
+	w = serve(f.AddDebtHandler, "POST /api/debts", http.MethodPost, "/api/debts", add+`, "override": true, "owner_pin": "1234"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("override: got %d; want 201", w.Code)
+	}
+	if len(f.debts.added) != 1 || f.debts.added[0].ClientID != 1 || f.debts.override == nil {
+		t.Errorf("added %+v with override %+v; want client 1's debt with the refused check", f.debts.added, f.debts.override)
+	}
+}
+
+func TestAddDebtHandlerInvalid(t *testing.T) {
+	f := newFakeServer()
+	tests := []struct {
+		body  string
+		field string
+	}{
+		{`{"fullname": "Bob", "phone": "0555000000", "amount": 0}`, "amount"},
+		{`{"fullname": "Bob", "phone": "12", "amount": 10, "photo_data": "/uploads/x.jpg"}`, "phone"},
+		{`{"fullname": "Bob", "phone": "0555000000", "amount": 10}`, "photo_data"},
+		{`{"fullname": "Bob", "phone": "0555000000", "amount": 10, "due_date": "19.10.2026"}`, "due_date"},
+	}
+	for _, tt := range tests {
+		w := serve(f.AddDebtHandler, "POST /api/debts", http.MethodPost, "/api/debts", tt.body)
+		body := expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+		if len(body.Details) != 1 || body.Details[0].Field != tt.field {
+			t.Errorf("%s: details = %+v; want field %q", tt.body, body.Details, tt.field)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"debtNote/i18n"
+	"debtNote/models"
+	"debtNote/phone"
+	"debtNote/repository"
+	"debtNote/service"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// ErrorResponse is the body of every failed API request. Programs go by Code, which stays the same
+// for the same kind of failure; Message is for the operator; Details lists the request fields at fault.
+type ErrorResponse struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Details []FieldError        `json:"details,omitempty"`
+	Credit  *models.CreditCheck `json:"credit,omitempty"` // Why a debt was refused
+}
+
+// FieldError says what is wrong with one field of the request body or one query parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error codes of the API. A refused debt is answered with the reason of its credit check
+// (models.CreditReasonLimitExceeded and the like) as the code.
+const (
+	CodeRouteNotFound    = "route_not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeInvalidBody      = "invalid_body"      // The body is not the JSON expected
+	CodeInvalidParameter = "invalid_parameter" // A query parameter is missing or malformed
+	CodeInvalidCursor    = "invalid_cursor"
+	CodeValidationFailed = "validation_failed" // The request is well-formed but breaks a rule
+	CodeOwnerPinRequired = "owner_pin_required"
+	CodeDebtNotFound     = "debt_not_found"
+	CodeClientNotFound   = "client_not_found"
+	CodePhoneNotFound    = "phone_not_found"
+	CodeDebtNotActive    = "debt_not_active"
+	CodeDebtNotDeleted   = "debt_not_deleted"
+	CodeDebtChanged      = "debt_changed"
+	CodeClientHasDebts   = "client_has_debts"
+	CodePhoneTaken       = "phone_taken"
+	CodeMainPhone        = "main_phone"
+	CodeInternal         = "internal_error"
+)
+
+// writeError sends an error response. message is already in the request's language.
+func writeError(w http.ResponseWriter, status int, code, message string, details ...FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Code: code, Message: message, Details: details})
+}
+
+// methodNotAllowed answers a request made with a method the endpoint does not take.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, tr(r, "method_not_allowed"))
+}
+
+// invalidBody answers a request whose body could not be decoded, naming the field when the JSON had a wrong type.
+func invalidBody(w http.ResponseWriter, r *http.Request, err error) {
+	message := tr(r, "invalid_body")
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidBody, message, FieldError{Field: typeErr.Field, Message: tr(r, "invalid_field_type")})
+		return
+	}
+	writeError(w, http.StatusBadRequest, CodeInvalidBody, message)
+}
+
+// invalidParameter answers a request with a missing or malformed query parameter,
+// explained by catalog message id.
+func invalidParameter(w http.ResponseWriter, r *http.Request, param, id string, args ...interface{}) {
+	message := tr(r, id, args...)
+	writeError(w, http.StatusBadRequest, CodeInvalidParameter, message, FieldError{Field: param, Message: message})
+}
+
+// invalidField answers a request whose body field breaks a rule, explained by catalog message id.
+func invalidField(w http.ResponseWriter, r *http.Request, field, id string, args ...interface{}) {
+	message := tr(r, id, args...)
+	writeError(w, http.StatusUnprocessableEntity, CodeValidationFailed, message, FieldError{Field: field, Message: message})
+}
+
+// internalError logs a failure that is not the client's fault and answers without its details,
+// which may come from the database.
+func internalError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	log.Printf("Failed to %s: %v", action, err)
+	writeError(w, http.StatusInternalServerError, CodeInternal, tr(r, "internal_error"))
+}
+
+// writeParamError answers a request whose query parameters could not be read:
+// a paramError is the client's fault, anything else (e.g. reading the settings) is internal.
+func writeParamError(w http.ResponseWriter, r *http.Request, err error) {
+	var pe *paramError
+	if errors.As(err, &pe) {
+		message := i18n.Translate(lang(r), pe.message)
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, message, FieldError{Field: pe.param, Message: message})
+		return
+	}
+	internalError(w, r, "read parameters", err)
+}
+
+// domainErrors gives the status and code of the errors the services and repositories refuse a request with.
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{service.ErrDebtNotFound, http.StatusNotFound, CodeDebtNotFound},
+	{service.ErrClientNotFound, http.StatusNotFound, CodeClientNotFound},
+	{service.ErrDebtNotActive, http.StatusConflict, CodeDebtNotActive},
+	{service.ErrDebtNotDeleted, http.StatusConflict, CodeDebtNotDeleted},
+	{service.ErrDebtChanged, http.StatusConflict, CodeDebtChanged},
+	{service.ErrClientHasDebts, http.StatusConflict, CodeClientHasDebts},
+	{service.ErrOwnerPinRequired, http.StatusForbidden, CodeOwnerPinRequired},
+	{repository.ErrPhoneTaken, http.StatusConflict, CodePhoneTaken},
+	{repository.ErrMainPhone, http.StatusConflict, CodeMainPhone},
+}
+
+// writeServiceError answers a request that a service or repository refused, with the status that fits the error.
+// Errors it does not know about are internal.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var validation *service.ValidationError
+	if errors.As(err, &validation) {
+		invalidField(w, r, validation.Field, validation.Message.ID, validation.Message.Args...)
+		return
+	}
+	if errors.Is(err, phone.ErrInvalid) {
+		invalidField(w, r, "phone", "invalid_phone")
+		return
+	}
+
+	var refused *service.CreditRefusedError
+	if errors.As(err, &refused) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Code:    refused.Credit.Reason,
+			Message: tr(r, refused.Credit.Reason),
+			Credit:  &refused.Credit,
+		})
+		return
+	}
+
+	for _, d := range domainErrors {
+		if errors.Is(err, d.err) {
+			writeError(w, d.status, d.code, i18n.Translate(lang(r), err))
+			return
+		}
+	}
+	internalError(w, r, "handle request", err)
+}
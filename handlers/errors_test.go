@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"debtNote/i18n"
+	"debtNote/models"
+	"debtNote/phone"
+	"debtNote/repository"
+	"debtNote/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteServiceError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string // Field named in the details, if any
+	}{
+		{"debt not found", service.ErrDebtNotFound, http.StatusNotFound, CodeDebtNotFound, ""},
+		{"client not found", service.ErrClientNotFound, http.StatusNotFound, CodeClientNotFound, ""},
+		{"debt not active", service.ErrDebtNotActive, http.StatusConflict, CodeDebtNotActive, ""},
+		{"debt not deleted", service.ErrDebtNotDeleted, http.StatusConflict, CodeDebtNotDeleted, ""},
+		{"debt changed", service.ErrDebtChanged, http.StatusConflict, CodeDebtChanged, ""},
+		{"debt changed in repository", repository.ErrDebtChanged, http.StatusConflict, CodeDebtChanged, ""},
+		{"client has debts", service.ErrClientHasDebts, http.StatusConflict, CodeClientHasDebts, ""},
+		{"owner pin", service.ErrOwnerPinRequired, http.StatusForbidden, CodeOwnerPinRequired, ""},
+		{"phone taken", repository.ErrPhoneTaken, http.StatusConflict, CodePhoneTaken, ""},
+		{"main phone", repository.ErrMainPhone, http.StatusConflict, CodeMainPhone, ""},
+		{"wrapped", fmt.Errorf("failed to pay: %w", service.ErrDebtNotActive), http.StatusConflict, CodeDebtNotActive, ""},
+		{"invalid phone", phone.ErrInvalid, http.StatusUnprocessableEntity, CodeValidationFailed, "phone"},
+		{"unknown", errors.New("disk I/O error"), http.StatusInternalServerError, CodeInternal, ""},
+		{"unknown message", i18n.New("debt_added"), http.StatusInternalServerError, CodeInternal, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeServiceError(w, httptest.NewRequest(http.MethodGet, "/api/debts", nil), tt.err)
+			body := expectError(t, w, tt.status, tt.code)
+			if tt.field != "" && (len(body.Details) != 1 || body.Details[0].Field != tt.field) {
+				t.Errorf("details = %+v; want field %q", body.Details, tt.field)
+			}
+			if tt.status == http.StatusInternalServerError && body.Message != i18n.T(i18n.Default, "internal_error") {
+				t.Errorf("internal error leaked %q", body.Message)
+			}
+		})
+	}
+}
+
+func TestWriteServiceErrorValidation(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/debts", nil)
+	r = r.WithContext(i18n.NewContext(r.Context(), i18n.English))
+	writeServiceError(w, r, &service.ValidationError{Field: "amount", Message: i18n.New("amount_not_positive")})
+
+	body := expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+	want := i18n.T(i18n.English, "amount_not_positive")
+	if body.Message != want || len(body.Details) != 1 || body.Details[0] != (FieldError{Field: "amount", Message: want}) {
+		t.Errorf("got %+v; want the English message for field amount", body)
+	}
+}
+
+func TestWriteServiceErrorCreditRefused(t *testing.T) {
+	limit := 500.0
+	credit := models.CreditCheck{Reason: models.CreditReasonLimitExceeded, Limit: &limit, Outstanding: 450, Requested: 100}
+	w := httptest.NewRecorder()
+	writeServiceError(w, httptest.NewRequest(http.MethodPost, "/api/debts", nil), &service.CreditRefusedError{Credit: credit})
+
+	body := expectError(t, w, http.StatusUnprocessableEntity, models.CreditReasonLimitExceeded)
+	if body.Credit == nil || body.Credit.Outstanding != 450 || *body.Credit.Limit != 500 {
+		t.Errorf("credit = %+v; want the refused check", body.Credit)
+	}
+}
+
+func TestWriteParamError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeParamError(w, httptest.NewRequest(http.MethodGet, "/api/debts", nil), invalidParam("min_amount", "min_above_max", "min_amount", "max_amount"))
+	body := expectError(t, w, http.StatusBadRequest, CodeInvalidParameter)
+	if len(body.Details) != 1 || body.Details[0].Field != "min_amount" {
+		t.Errorf("details = %+v; want field min_amount", body.Details)
+	}
+
+	w = httptest.NewRecorder()
+	writeParamError(w, httptest.NewRequest(http.MethodGet, "/api/debts", nil), errors.New("no such table: settings"))
+	expectError(t, w, http.StatusInternalServerError, CodeInternal)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"debtNote/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"time"
+)
+
+// ExportClientHandler hands over everything stored about a client as a ZIP:
+// client.json, debts.json, debt_payments.json, audit.json and the photo files under photos/.
+func (s *Server) ExportClientHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, err := parseID(r, "client_id")
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	export, err := s.Clients.GetClientExport(r.Context(), clientID)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, CodeClientNotFound, tr(r, "client_not_found"))
+		return
+	} else if err != nil {
+		internalError(w, r, "export client", err)
+		return
+	}
+
+	// Built in memory so a failure can still be reported instead of sending half an archive
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"client.json", map[string]interface{}{"exported_at": export.ExportedAt, "client": export.Client, "phones": export.Phones}},
+		{"debts.json", export.Debts},
+		{"debt_payments.json", export.Payments},
+		{"audit.json", export.Audit},
+	}
+	for _, f := range files {
+		if err := writeZipJSON(archive, f.name, f.data); err != nil {
+			internalError(w, r, "export client", err)
+			return
+		}
+	}
+
+	if filePath, ok := service.PhotoFile(export.Client.PhotoData); ok {
+		data, err := os.ReadFile(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			internalError(w, r, "read photo", err)
+			return
+		}
+		// A photo lost from disk is simply not in the archive
+		if err == nil {
+			if err := writeZipFile(archive, "photos/"+path.Base(export.Client.PhotoData), data); err != nil {
+				internalError(w, r, "export client", err)
+				return
+			}
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		internalError(w, r, "export client", err)
+		return
+	}
+
+	filename := fmt.Sprintf("client-%d-%s.zip", clientID, time.Now().Format(dateLayout))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Write(buf.Bytes())
+}
+
+// writeZipJSON adds v to the archive as indented JSON.
+func writeZipJSON(archive *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(archive, name, data)
+}
+
+func writeZipFile(archive *zip.Writer, name string, data []byte) error {
+	f, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+	"debtNote/repository"
+	"debtNote/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// The fakes keep what the handlers store in memory. Each embeds its repository interface,
+// so a method a test did not expect to be called panics on the nil interface.
+
+type fakeDebts struct {
+	repository.DebtRepository
+	debts    map[int64]models.Debt
+	payments []models.DebtPayment
+	added    []models.Debt
+	override *models.CreditCheck
+}
+
+func (f *fakeDebts) GetDebt(ctx context.Context, debtID int64) (models.Debt, error) {
+	debt, ok := f.debts[debtID]
+	if !ok {
+		return debt, sql.ErrNoRows
+	}
+	return debt, nil
+}
+
+func (f *fakeDebts) GetDebtDetail(ctx context.Context, debtID int64) (models.DebtDetail, error) {
+	debt, err := f.GetDebt(ctx, debtID)
+	return models.DebtDetail{Debt: debt, OriginalAmount: debt.Amount}, err
+}
+
+func (f *fakeDebts) AddDebt(ctx context.Context, debt models.Debt, guarantorIDs []int64, override *models.CreditCheck) (int64, error) {
+	f.added = append(f.added, debt)
+	f.override = override
+	return int64(len(f.added)), nil
+}
+
+func (f *fakeDebts) MakePayment(ctx context.Context, payment models.DebtPayment, owed float64, rating models.DebtRating) error {
+	debt := f.debts[payment.DebtID]
+	if debt.Status != models.StatusActive || debt.Amount != owed {
+		return repository.ErrDebtChanged
+	}
+	debt.Amount = payment.RemainingAmount
+	if debt.Amount == 0 {
+		debt.Status, debt.Rating = models.StatusPaid, rating
+	}
+	f.debts[payment.DebtID] = debt
+	f.payments = append(f.payments, payment)
+	return nil
+}
+
+func (f *fakeDebts) DeleteDebt(ctx context.Context, debtID int64, comment string) error {
+	debt := f.debts[debtID]
+	debt.Status, debt.DeleteComment = models.StatusDeleted, comment
+	f.debts[debtID] = debt
+	return nil
+}
+
+type fakeClients struct {
+	repository.ClientRepository
+	clients map[int64]models.Client
+	credit  models.CreditCheck
+	limits  map[int64]*float64
+}
+
+func (f *fakeClients) FindClientIDByPhone(ctx context.Context, raw string) (int64, error) {
+	for id, c := range f.clients {
+		if c.Phone == raw {
+			return id, nil
+		}
+	}
+	return 0, nil
+}
+
+func (f *fakeClients) FindOrCreateClient(ctx context.Context, client models.Client) (int64, error) {
+	if id, _ := f.FindClientIDByPhone(ctx, client.Phone); id != 0 {
+		return id, nil
+	}
+	id := int64(len(f.clients) + 1)
+	f.clients[id] = client
+	return id, nil
+}
+
+func (f *fakeClients) GetClient(ctx context.Context, clientID int64) (models.Client, error) {
+	client, ok := f.clients[clientID]
+	if !ok {
+		return client, sql.ErrNoRows
+	}
+	return client, nil
+}
+
+func (f *fakeClients) CheckCredit(ctx context.Context, clientID int64, amount float64) (models.CreditCheck, error) {
+	check := f.credit
+	check.Requested = amount
+	return check, nil
+}
+
+func (f *fakeClients) SetCreditLimit(ctx context.Context, clientID int64, limit *float64) error {
+	if _, ok := f.clients[clientID]; !ok {
+		return sql.ErrNoRows
+	}
+	f.limits[clientID] = limit
+	return nil
+}
+
+func (f *fakeClients) SetClientBlocked(ctx context.Context, clientID int64, blocked bool, reason string) error {
+	client, ok := f.clients[clientID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	client.Blocked, client.BlockedReason = blocked, reason
+	f.clients[clientID] = client
+	return nil
+}
+
+type fakeAudit struct {
+	repository.AuditRepository
+	entries []models.AuditEntry
+}
+
+func (f *fakeAudit) AddAuditEntry(ctx context.Context, entity string, entityID int64, action, details string) error {
+	f.entries = append(f.entries, models.AuditEntry{Entity: entity, EntityID: entityID, Action: action, Details: details})
+	return nil
+}
+
+type fakeSettings struct {
+	values map[string]string
+	reads  int // Calls to GetSetting
+}
+
+func (f *fakeSettings) GetSetting(ctx context.Context, key string) (string, error) {
+	f.reads++
+	return f.values[key], nil
+}
+
+func (f *fakeSettings) GetSettings(ctx context.Context) (map[string]string, error) {
+	return f.values, nil
+}
+
+func (f *fakeSettings) SetSettings(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		f.values[key] = value
+	}
+	return nil
+}
+
+// fakeServer is a Server on fresh fakes, with the services on top of them.
+type fakeServer struct {
+	*Server
+	debts    *fakeDebts
+	clients  *fakeClients
+	audit    *fakeAudit
+	settings *fakeSettings
+}
+
+func newFakeServer() *fakeServer {
+	f := &fakeServer{
+		debts:    &fakeDebts{debts: map[int64]models.Debt{}},
+		clients:  &fakeClients{clients: map[int64]models.Client{}, credit: models.CreditCheck{Allowed: true}, limits: map[int64]*float64{}},
+		audit:    &fakeAudit{},
+		settings: &fakeSettings{values: map[string]string{}},
+	}
+	f.Server = &Server{
+		Clients:  f.clients,
+		Debts:    f.debts,
+		Settings: f.settings,
+		Audit:    f.audit,
+
+		DebtService:   &service.Debts{Clients: f.clients, Debts: f.debts, Audit: f.audit},
+		ClientService: &service.Clients{Clients: f.clients, Audit: f.audit},
+	}
+	return f
+}
+
+// serve runs a request against handler registered on pattern, as main registers it.
+func serve(handler http.HandlerFunc, pattern, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, handler)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+// expectError checks that w is an error response with status and code.
+func expectError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) ErrorResponse {
+	t.Helper()
+	var body ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("error body: %v", err)
+	}
+	if w.Code != status || body.Code != code {
+		t.Errorf("got %d %q (%s); want %d %q", w.Code, body.Code, body.Message, status, code)
+	}
+	if body.Message == "" {
+		t.Error("error without a message")
+	}
+	return body
+}
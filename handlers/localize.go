@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"debtNote/i18n"
+	"debtNote/models"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Localize picks the language API requests are answered in and stores it in the request context:
+// the shop's "language" setting when it is set, else the browser's Accept-Language when it names a
+// translated language, else i18n.Default.
+func (s *Server) Localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			r = r.WithContext(i18n.NewContext(r.Context(), s.requestLanguage(r)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestLanguage is the language Localize picks for r.
+func (s *Server) requestLanguage(r *http.Request) i18n.Lang {
+	if lang, ok := i18n.Parse(s.languageSetting(r.Context())); ok {
+		return lang
+	}
+	if lang, ok := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language")); ok {
+		return lang
+	}
+	return i18n.Default
+}
+
+// languageSetting returns the shop's "language" setting. It is read once and kept until
+// UpdateSettingsHandler changes it, so requests don't each cost a query.
+func (s *Server) languageSetting(ctx context.Context) string {
+	if cached := s.language.Load(); cached != nil {
+		return *cached
+	}
+	setting, err := s.Settings.GetSetting(ctx, models.SettingLanguage)
+	if err != nil {
+		// Answering in the default language is better than not answering; try again next time
+		log.Printf("Failed to read language setting: %v", err)
+		return ""
+	}
+	s.language.Store(&setting)
+	return setting
+}
+
+// lang returns the language r is answered in.
+func lang(r *http.Request) i18n.Lang {
+	return i18n.FromContext(r.Context())
+}
+
+// tr returns catalog message id in the language r is answered in.
+func tr(r *http.Request, id string, args ...interface{}) string {
+	return i18n.T(lang(r), id, args...)
+}
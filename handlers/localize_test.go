@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"debtNote/i18n"
+	"debtNote/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	f := newFakeServer()
+	var got i18n.Lang
+	handler := f.Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = lang(r)
+	}))
+	request := func(acceptLanguage string) i18n.Lang {
+		r := httptest.NewRequest(http.MethodGet, "/api/debts", nil)
+		r.Header.Set("Accept-Language", acceptLanguage)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return got
+	}
+
+	tests := []struct {
+		setting        string
+		acceptLanguage string
+		want           i18n.Lang
+	}{
+		{"", "", i18n.Default},
+		{"", "de-DE,de;q=0.9", i18n.Default},
+		{"", "ru-RU,ru;q=0.9,en;q=0.8", i18n.Russian}, // Without the setting the browser's language is used
+		{"", "de,en;q=0.5,ru;q=0.7", i18n.Russian},
+		{"en", "", i18n.English},
+		{"en", "de", i18n.English},
+		{"en", "ky-KG", i18n.English}, // The setting wins over the browser's language
+		{"ru", "en-US,en;q=0.9", i18n.Russian},
+	}
+	for _, tt := range tests {
+		f.settings.values[models.SettingLanguage] = tt.setting
+		f.language.Store(nil)
+		if got := request(tt.acceptLanguage); got != tt.want {
+			t.Errorf("setting %q, Accept-Language %q: answered in %q; want %q", tt.setting, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizeCachesSetting(t *testing.T) {
+	t.Setenv("DEBTNOTE_OWNER_PIN", "1234")
+	f := newFakeServer()
+	f.settings.values[models.SettingLanguage] = "ru"
+	handler := f.Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/debts", nil))
+	}
+	if f.settings.reads != 1 {
+		t.Errorf("language setting read %d times for 3 requests; want 1", f.settings.reads)
+	}
+
+	// Requests outside the API are not localized
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	if f.settings.reads != 1 {
+		t.Errorf("language setting read for a static file")
+	}
+
+	// Changing the setting is seen by the next request
+	serve(f.UpdateSettingsHandler, "PUT /api/settings", http.MethodPut, "/api/settings", `{"language": "en"}`)
+	var got i18n.Lang
+	f.Localize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = lang(r)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/debts", nil))
+	if got != i18n.English {
+		t.Errorf("after changing the setting to en: answered in %q", got)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"debtNote/i18n"
+	"debtNote/models"
+	"debtNote/repository"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxPageLimit caps the "limit" parameter of paged lists.
+const maxPageLimit = 1000
+
+// dateLayout is the format of every date query parameter (the same as <input type="date">).
+const dateLayout = "2006-01-02"
+
+// paramError reports a query parameter that is malformed or missing. message is
+// translated when the error is answered, so it is usually an *i18n.Message.
+type paramError struct {
+	param   string
+	message error
+}
+
+func (e *paramError) Error() string {
+	return e.message.Error()
+}
+
+// invalidParam returns a paramError for param with catalog message id.
+func invalidParam(param, id string, args ...interface{}) error {
+	return &paramError{param: param, message: i18n.New(id, args...)}
+}
+
+// parseDateRange reads the optional "from" and "to" query parameters as dates in loc.
+// The returned "to" is exclusive (the start of the day after), so both days are included.
+func parseDateRange(r *http.Request, loc *time.Location) (time.Time, time.Time, error) {
+	return parseNamedDateRange(r, "from", "to", loc)
+}
+
+// parseNamedDateRange is parseDateRange for other parameter names, e.g. "paid_from" and "paid_to".
+func parseNamedDateRange(r *http.Request, fromKey, toKey string, loc *time.Location) (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if s := r.URL.Query().Get(fromKey); s != "" {
+		t, err := time.ParseInLocation(dateLayout, s, loc)
+		if err != nil {
+			return from, to, invalidParam(fromKey, "invalid_date", fromKey, s)
+		}
+		from = t
+	}
+
+	if s := r.URL.Query().Get(toKey); s != "" {
+		t, err := time.ParseInLocation(dateLayout, s, loc)
+		if err != nil {
+			return from, to, invalidParam(toKey, "invalid_date", toKey, s)
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, invalidParam(fromKey, "date_range_reversed", fromKey, toKey)
+	}
+
+	return from, to, nil
+}
+
+// parseDay reads the legacy single-day "date" parameter as a one-day range in loc.
+// Both values are zero when it is absent.
+func parseDay(r *http.Request, loc *time.Location) (time.Time, time.Time, error) {
+	s := r.URL.Query().Get("date")
+	if s == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, s, loc)
+	if err != nil {
+		return t, t, invalidParam("date", "invalid_date", "date", s)
+	}
+	return t, t.AddDate(0, 0, 1), nil
+}
+
+// parseAmount reads an optional non-negative amount query parameter; nil when it is absent.
+func parseAmount(r *http.Request, key string) (*float64, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, invalidParam(key, "invalid_amount", key, s)
+	}
+	return &v, nil
+}
+
+// parseBool reads an optional true/false query parameter; nil when it is absent.
+func parseBool(r *http.Request, key string) (*bool, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, invalidParam(key, "invalid_bool", key, s)
+	}
+	return &v, nil
+}
+
+// parseID reads a required ID: the {id} path parameter of a resource route such as "/api/debts/{id}/payments",
+// else the query parameter key (e.g. "client_id") of the old route.
+func parseID(r *http.Request, key string) (int64, error) {
+	s := r.URL.Query().Get(key)
+	if v := r.PathValue("id"); v != "" {
+		key, s = "id", v
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, invalidParam(key, "invalid_id", key)
+	}
+	return id, nil
+}
+
+// decodeBody decodes the JSON body of r into v. Resource routes carry the ID in the path,
+// so there an empty body is fine when nothing else is needed (e.g. restoring a debt).
+func decodeBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF && r.PathValue("id") != "" {
+		return nil
+	}
+	return err
+}
+
+// pathID sets *id, decoded from the body of an old route, to the {id} path parameter when the route has one.
+func pathID(r *http.Request, id *int64) error {
+	if r.PathValue("id") == "" {
+		return nil
+	}
+	v, err := parseID(r, "id")
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+// parsePage reads paging parameters: "page" and "limit" for numbered pages, or "cursor" (empty for
+// the first page) for keyset pages, whose total is only counted with "total=true".
+func parsePage(r *http.Request, defaultLimit int) repository.Page {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxPageLimit)
+	// Keep the OFFSET of the page, (page-1)*limit, from overflowing
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	page = max(1, min(page, math.MaxInt/maxPageLimit))
+	withTotal, _ := strconv.ParseBool(r.URL.Query().Get("total"))
+
+	return repository.Page{
+		Number:    page,
+		Limit:     limit,
+		Keyset:    r.URL.Query().Has("cursor"),
+		Cursor:    r.URL.Query().Get("cursor"),
+		WithTotal: withTotal,
+	}
+}
+
+// pageResponse wraps a page of data as the client asked for it: numbered pages always carry
+// the total, cursor pages carry next_cursor and the total only if it was counted.
+func pageResponse(data interface{}, page repository.Page, result repository.PageResult) interface{} {
+	if !page.Keyset {
+		return PaginatedResponse{Data: data, Total: *result.Total}
+	}
+	return CursorResponse{Data: data, NextCursor: result.NextCursor, Total: result.Total}
+}
+
+// reportLocation picks the time zone for date grouping: the "tz" parameter, else the shop setting,
+// else the server's local zone.
+func (s *Server) reportLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		var err error
+		name, err = s.Settings.GetSetting(r.Context(), models.SettingTimeZone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, invalidParam("tz", "invalid_time_zone", name)
+	}
+	return loc, nil
+}
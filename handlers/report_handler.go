@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"debtNote/models"
+	"debtNote/repository"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetAgingReportHandler returns active balances grouped by age.
+// Bucket bounds come from the "buckets" parameter ("30,60,90") or the shop setting.
+// With format=csv the report is sent as a CSV download instead of JSON.
+func (s *Server) GetAgingReportHandler(w http.ResponseWriter, r *http.Request) {
+	bucketsParam := r.URL.Query().Get("buckets")
+	if bucketsParam == "" {
+		var err error
+		bucketsParam, err = s.Settings.GetSetting(r.Context(), models.SettingAgingBuckets)
+		if err != nil {
+			internalError(w, r, "get settings", err)
+			return
+		}
+	}
+
+	bounds, err := repository.ParseAgingBuckets(bucketsParam)
+	if err != nil {
+		writeParamError(w, r, &paramError{param: "buckets", message: err})
+		return
+	}
+
+	loc, err := s.reportLocation(r)
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	report, err := s.Reports.GetAgingReport(r.Context(), bounds, time.Now().In(loc))
+	if err != nil {
+		internalError(w, r, "build aging report", err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		writeAgingCSV(w, r, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		internalError(w, r, "encode response", err)
+	}
+}
+
+// writeAgingCSV writes the aging report as a CSV attachment, one row per client plus a totals row,
+// with the headings in the language r is answered in.
+func writeAgingCSV(w http.ResponseWriter, r *http.Request, report models.AgingReport) {
+	filename := fmt.Sprintf("aging-%s.csv", report.AsOf.Format(dateLayout))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	// UTF-8 BOM so spreadsheet programs show Cyrillic names correctly
+	w.Write([]byte("\xEF\xBB\xBF"))
+
+	cw := csv.NewWriter(w)
+
+	header := []string{tr(r, "export_client_id"), tr(r, "export_fullname"), tr(r, "export_phone")}
+	for _, b := range report.Buckets {
+		header = append(header, tr(r, "export_days", b.Label))
+	}
+	header = append(header, tr(r, "export_total"))
+	cw.Write(header)
+
+	for _, row := range report.Rows {
+		record := []string{strconv.FormatInt(row.ClientID, 10), row.Fullname, row.Phone}
+		for _, amount := range row.Amounts {
+			record = append(record, formatAmount(amount))
+		}
+		record = append(record, formatAmount(row.Total))
+		cw.Write(record)
+	}
+
+	totals := []string{"", tr(r, "export_total"), ""}
+	for _, amount := range report.Totals {
+		totals = append(totals, formatAmount(amount))
+	}
+	totals = append(totals, formatAmount(report.GrandTotal))
+	cw.Write(totals)
+
+	cw.Flush()
+}
+
+// formatAmount prints a money amount without needless trailing zeros.
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// GetTimeSeriesHandler returns credit issued vs collected per "interval" (day, week or month)
+// between the optional "from"/"to" dates, grouped in the shop time zone or the "tz" parameter.
+func (s *Server) GetTimeSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	interval := r.URL.Query().Get("interval")
+	switch interval {
+	case "":
+		interval = repository.IntervalDay
+	case repository.IntervalDay, repository.IntervalWeek, repository.IntervalMonth:
+	default:
+		invalidParameter(w, r, "interval", "invalid_choice", "interval", interval, "day, week, month")
+		return
+	}
+
+	loc, err := s.reportLocation(r)
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	from, to, err := parseDateRange(r, loc)
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	series, err := s.Reports.GetTimeSeries(r.Context(), interval, from, to, loc)
+	if err != nil {
+		internalError(w, r, "build time series", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(series); err != nil {
+		internalError(w, r, "encode response", err)
+	}
+}
+
+// GetClientRisksHandler returns clients ranked by risk score, sorted by sort_by: risk (default),
+// balance, active, oldest or name. It is paged like the other lists, by number or by cursor.
+func (s *Server) GetClientRisksHandler(w http.ResponseWriter, r *http.Request) {
+	sortBy := r.URL.Query().Get("sort_by")
+	switch sortBy {
+	case "", "risk", "balance", "active", "oldest", "name":
+	default:
+		invalidParameter(w, r, "sort_by", "invalid_choice", "sort_by", sortBy, "risk, balance, active, oldest, name")
+		return
+	}
+
+	page := parsePage(r, 20)
+
+	risks, result, err := s.Reports.GetClientRisks(r.Context(), sortBy, page)
+	if err == repository.ErrInvalidCursor {
+		message := tr(r, "invalid_cursor")
+		writeError(w, http.StatusBadRequest, CodeInvalidCursor, message, FieldError{Field: "cursor", Message: message})
+		return
+	}
+	if err != nil {
+		internalError(w, r, "rank clients", err)
+		return
+	}
+
+	response := pageResponse(risks, page, result)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		internalError(w, r, "encode response", err)
+	}
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetClientRisksHandlerInvalid(t *testing.T) {
+	f := newFakeServer()
+	w := serve(f.GetClientRisksHandler, "GET /api/reports/risk", http.MethodGet, "/api/reports/risk?sort_by=score", "")
+	body := expectError(t, w, http.StatusBadRequest, CodeInvalidParameter)
+	if len(body.Details) != 1 || body.Details[0].Field != "sort_by" {
+		t.Errorf("details = %+v; want sort_by", body.Details)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"debtNote/service"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// RetentionHandler applies the configured retention policy. GET previews what would be purged;
+// POST purges it now instead of waiting for the daily run, and needs the owner's PIN.
+func (s *Server) RetentionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var payload struct {
+			OwnerPin string `json:"owner_pin"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+			invalidBody(w, r, err)
+			return
+		}
+		if err := service.CheckOwner(payload.OwnerPin); err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+	}
+
+	policy, err := s.RetentionService.Policy(r.Context())
+	if err != nil {
+		internalError(w, r, "load retention policy", err)
+		return
+	}
+
+	report, err := s.RetentionService.Run(r.Context(), policy, time.Now(), r.Method == http.MethodGet)
+	if err != nil {
+		internalError(w, r, "purge debts", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
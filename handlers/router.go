@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routeMethods are the methods the API routes are registered with.
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+
+// NoRouteHandler answers API requests that mux has no route for, in the error envelope
+// (ServeMux itself answers in plain text): 405 with an Allow header when the path takes
+// other methods, else 404. It is registered on mux as "/api/".
+func NoRouteHandler(mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if allowed := allowedMethods(mux, r, false); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			methodNotAllowed(w, r)
+			return
+		}
+		writeError(w, http.StatusNotFound, CodeRouteNotFound, tr(r, "route_not_found"))
+	}
+}
+
+// GuardOldRoutes keeps the paths of old action routes from being taken for a resource's {id}:
+// "GET /api/debts/pay" would match "GET /api/debts/{id}" and be refused as an invalid ID.
+// A request to such a path with a method its route does not take is answered 405 with an
+// Allow header instead; everything else goes on to mux.
+func GuardOldRoutes(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); strings.Contains(pattern, "{id}") {
+			if allowed := allowedMethods(mux, r, true); len(allowed) > 0 {
+				w.Header().Set("Allow", strings.Join(allowed, ", "))
+				methodNotAllowed(w, r)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedMethods lists the methods mux has a route for at r's path, leaving out the catch-all
+// routes and, with literalOnly, routes with wildcards.
+func allowedMethods(mux *http.ServeMux, r *http.Request, literalOnly bool) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		_, pattern := mux.Handler(probe)
+		if pattern == "" || pattern == "/api/" || pattern == "/" {
+			continue
+		}
+		if literalOnly && strings.Contains(pattern, "{") {
+			continue
+		}
+		allowed = append(allowed, method)
+	}
+	return allowed
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouting(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := parseID(r, "debt_id"); err != nil {
+			writeParamError(w, r, err)
+		}
+	}
+
+	// A few routes laid out like main's
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/debts", ok)
+	mux.HandleFunc("POST /api/debts", ok)
+	mux.HandleFunc("GET /api/debts/{id}", ok)
+	mux.HandleFunc("DELETE /api/debts/{id}", ok)
+	mux.HandleFunc("POST /api/debts/{id}/payments", ok)
+	mux.HandleFunc("GET /api/clients/search", ok)
+	mux.HandleFunc("DELETE /api/clients/{id}", ok)
+	mux.HandleFunc("POST /api/debts/add", ok)
+	mux.HandleFunc("POST /api/debts/pay", ok)
+	mux.HandleFunc("POST /api/clients/delete", ok)
+	mux.Handle("/api/", NoRouteHandler(mux))
+	handler := GuardOldRoutes(mux)
+
+	tests := []struct {
+		method, target string
+		status         int
+		code           string
+		allow          string
+	}{
+		{http.MethodGet, "/api/debts/5", http.StatusOK, "", ""},
+		{http.MethodDelete, "/api/debts/5", http.StatusOK, "", ""},
+		{http.MethodGet, "/api/debts/abc", http.StatusBadRequest, CodeInvalidParameter, ""},
+		{http.MethodPut, "/api/debts/5", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET, DELETE"},
+		{http.MethodDelete, "/api/debts", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET, POST"},
+		{http.MethodGet, "/api/nothing", http.StatusNotFound, CodeRouteNotFound, ""},
+		// Old action paths would otherwise be taken for an {id}
+		{http.MethodGet, "/api/debts/add", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "POST"},
+		{http.MethodGet, "/api/debts/pay", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "POST"},
+		{http.MethodDelete, "/api/debts/pay", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "POST"},
+		{http.MethodDelete, "/api/clients/delete", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "POST"},
+		{http.MethodPost, "/api/debts/pay", http.StatusOK, "", ""},
+		{http.MethodDelete, "/api/clients/search", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target+"?debt_id=1", nil))
+		if tt.code == "" {
+			if w.Code != tt.status {
+				t.Errorf("%s %s: got %d; want %d", tt.method, tt.target, w.Code, tt.status)
+			}
+			continue
+		}
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			expectError(t, w, tt.status, tt.code)
+			if allow := w.Header().Get("Allow"); allow != tt.allow {
+				t.Errorf("Allow = %q; want %q", allow, tt.allow)
+			}
+		})
+	}
+}
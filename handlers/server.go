@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"debtNote/repository"
+	"debtNote/service"
+	"sync/atomic"
+)
+
+// Server is what the HTTP handlers work with: the repositories of one shop, and the services
+// that change them by the shop's rules. It is built in main; a fake of any repository can be put in its place.
+type Server struct {
+	Clients  repository.ClientRepository
+	Debts    repository.DebtRepository
+	Settings repository.SettingsRepository
+	Audit    repository.AuditRepository
+	Reports  repository.ReportRepository
+
+	DebtService      *service.Debts
+	ClientService    *service.Clients
+	RetentionService *service.Retention
+
+	language atomic.Pointer[string] // Cached "language" setting; nil until read
+}
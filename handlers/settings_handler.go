@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"debtNote/i18n"
+	"debtNote/models"
+	"debtNote/repository"
+	"debtNote/service"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// settingValidators checks a new value before it is stored. Every known setting must have one.
+var settingValidators = map[string]func(string) error{
+	models.SettingAgingBuckets: func(v string) error {
+		_, err := repository.ParseAgingBuckets(v)
+		return err
+	},
+	models.SettingReputationWeights: func(v string) error {
+		_, err := repository.ParseReputationWeights(v)
+		return err
+	},
+	models.SettingDefaultTermDays: func(v string) error {
+		_, err := repository.ParseTermDays(v)
+		return err
+	},
+	models.SettingDefaultCreditLimit: func(v string) error {
+		_, err := repository.ParseCreditLimit(v)
+		return err
+	},
+	models.SettingBlockedReputations: func(v string) error {
+		_, err := repository.ParseBlockedReputations(v)
+		return err
+	},
+	models.SettingRetentionDeletedMonths: func(v string) error {
+		_, err := repository.ParseRetentionPeriod(v)
+		return err
+	},
+	models.SettingRetentionPaidYears: func(v string) error {
+		_, err := repository.ParseRetentionPeriod(v)
+		return err
+	},
+	models.SettingTimeZone: func(v string) error {
+		// "" is accepted and means server time
+		if _, err := time.LoadLocation(v); err != nil {
+			return i18n.New("invalid_time_zone", v)
+		}
+		return nil
+	},
+	models.SettingLanguage: func(v string) error {
+		if _, ok := i18n.Parse(v); !ok && v != "" {
+			return i18n.New("invalid_language", v)
+		}
+		return nil
+	},
+}
+
+// ownerSettings are the settings only the owner may change: they decide who gets credit, through
+// limits and reputation, and when debts are purged.
+var ownerSettings = map[string]bool{
+	models.SettingReputationWeights:      true,
+	models.SettingDefaultTermDays:        true,
+	models.SettingDefaultCreditLimit:     true,
+	models.SettingBlockedReputations:     true,
+	models.SettingRetentionDeletedMonths: true,
+	models.SettingRetentionPaidYears:     true,
+}
+
+// GetSettingsHandler returns all shop settings.
+func (s *Server) GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.Settings.GetSettings(r.Context())
+	if err != nil {
+		internalError(w, r, "get settings", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateSettingsHandler stores the settings given as a JSON object of key/value strings.
+// Changing an owner-only setting needs the owner's PIN in the "owner_pin" key.
+func (s *Server) UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		invalidBody(w, r, err)
+		return
+	}
+	ownerPin := payload["owner_pin"]
+	delete(payload, "owner_pin")
+
+	// Validate everything first so a bad value doesn't leave a half-applied update
+	for key, value := range payload {
+		validate, ok := settingValidators[key]
+		if !ok {
+			invalidField(w, r, key, "unknown_setting", key)
+			return
+		}
+		if err := validate(value); err != nil {
+			invalidField(w, r, key, "invalid_setting", key, i18n.Translate(lang(r), err))
+			return
+		}
+	}
+	for key := range payload {
+		if !ownerSettings[key] {
+			continue
+		}
+		if err := service.CheckOwner(ownerPin); err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		break
+	}
+
+	if err := s.Settings.SetSettings(r.Context(), payload); err != nil {
+		internalError(w, r, "save settings", err)
+		return
+	}
+	if _, ok := payload[models.SettingLanguage]; ok {
+		s.language.Store(nil)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, "settings_saved")})
+}
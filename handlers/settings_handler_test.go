@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"debtNote/models"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestUpdateSettingsHandler(t *testing.T) {
+	t.Setenv("DEBTNOTE_OWNER_PIN", "1234")
+	f := newFakeServer()
+
+	w := serve(f.UpdateSettingsHandler, "PUT /api/settings", http.MethodPut, "/api/settings", `{"aging_buckets": "7,14", "language": "ru"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d; want 200", w.Code)
+	}
+	if f.settings.values[models.SettingAgingBuckets] != "7,14" || f.settings.values[models.SettingLanguage] != "ru" {
+		t.Errorf("settings = %v", f.settings.values)
+	}
+
+	w = serve(f.UpdateSettingsHandler, "PUT /api/settings", http.MethodPut, "/api/settings", `{"colour": "red"}`)
+	expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+
+	// A bad value leaves the others unsaved too
+	w = serve(f.UpdateSettingsHandler, "PUT /api/settings", http.MethodPut, "/api/settings", `{"language": "en", "aging_buckets": "60,30"}`)
+	expectError(t, w, http.StatusUnprocessableEntity, CodeValidationFailed)
+	if f.settings.values[models.SettingLanguage] != "ru" {
+		t.Errorf("language = %q after a failed update; want ru", f.settings.values[models.SettingLanguage])
+	}
+}
+
+func TestUpdateSettingsHandlerOwner(t *testing.T) {
+	t.Setenv("DEBTNOTE_OWNER_PIN", "1234")
+	for key, value := range map[string]string{
+		models.SettingReputationWeights:      `{"overdue_penalty": 30}`,
+		models.SettingDefaultTermDays:        "14",
+		models.SettingDefaultCreditLimit:     "0",
+		models.SettingBlockedReputations:     "bad",
+		models.SettingRetentionDeletedMonths: "0",
+		models.SettingRetentionPaidYears:     "0",
+	} {
+		f := newFakeServer()
+		setting := strconv.Quote(key) + ": " + strconv.Quote(value)
+
+		for _, pin := range []string{``, `, "owner_pin": "0000"`} {
+			w := serve(f.UpdateSettingsHandler, "PUT /api/settings", http.MethodPut, "/api/settings", `{"language": "en", `+setting+pin+`}`)
+			expectError(t, w, http.StatusForbidden, CodeOwnerPinRequired)
+		}
+		if len(f.settings.values) != 0 {
+			t.Fatalf("%s: saved without the owner's PIN: %v", key, f.settings.values)
+		}
+
+		w := serve(f.UpdateSettingsHandler, "PUT /api/settings", http.MethodPut, "/api/settings", `{`+setting+`, "owner_pin": "1234"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s with the owner's PIN: got %d; want 200", key, w.Code)
+		}
+		if f.settings.values[key] != value {
+			t.Errorf("%s = %q; want %q", key, f.settings.values[key], value)
+		}
+		if _, saved := f.settings.values["owner_pin"]; saved {
+			t.Error("owner_pin was saved as a setting")
+		}
+	}
+}
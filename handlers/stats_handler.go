@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetStatsHandler returns the dashboard overview, optionally limited by "from"/"to" dates.
+func (s *Server) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := s.reportLocation(r)
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	from, to, err := parseDateRange(r, loc)
+	if err != nil {
+		writeParamError(w, r, err)
+		return
+	}
+
+	stats, err := s.Reports.GetStats(r.Context(), from, to, loc)
+	if err != nil {
+		internalError(w, r, "compute stats", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		internalError(w, r, "encode response", err)
+	}
+}
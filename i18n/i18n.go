@@ -0,0 +1,130 @@
+// Package i18n translates what the program tells people (API messages and exports) into
+// Kyrgyz, Russian or English. Messages are looked up in a catalog by a stable ID.
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang is a language the catalog is translated to, as an ISO 639-1 code.
+type Lang string
+
+const (
+	Kyrgyz  Lang = "ky"
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// Default is used when neither the request nor the settings pick a language: the shop staff's own.
+const Default = Kyrgyz
+
+// Parse reads a language tag such as "ru" or "ru-RU". It reports false for languages without a translation.
+func Parse(tag string) (Lang, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch lang := Lang(base); lang {
+	case Kyrgyz, Russian, English:
+		return lang, true
+	}
+	return "", false
+}
+
+// FromAcceptLanguage picks the translated language the client prefers most in an Accept-Language header,
+// e.g. "ru-RU,ru;q=0.9,en;q=0.8". It reports false when none of them is translated.
+func FromAcceptLanguage(header string) (Lang, bool) {
+	type choice struct {
+		lang Lang
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+	if len(choices) == 0 {
+		return "", false
+	}
+	// Stable, so equally weighted languages keep the client's order
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].lang, true
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying lang.
+func NewContext(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// FromContext returns the language stored by NewContext, or Default.
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := ctx.Value(contextKey{}).(Lang); ok {
+		return lang
+	}
+	return Default
+}
+
+// T returns message id in lang, formatted with args like fmt.Sprintf. A message missing in lang
+// is given in Default; an unknown id is returned as is.
+func T(lang Lang, id string, args ...interface{}) string {
+	translations, ok := catalog[id]
+	if !ok {
+		return id
+	}
+	format, ok := translations[lang]
+	if !ok {
+		format = translations[Default]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Message is a text from the catalog with its arguments, to be translated once the reader's language is known.
+// It is also an error, so packages below the API can fail with text the API shows in the client's language.
+type Message struct {
+	ID   string
+	Args []interface{}
+}
+
+// New returns the message id with args.
+func New(id string, args ...interface{}) *Message {
+	return &Message{ID: id, Args: args}
+}
+
+// In returns the message in lang.
+func (m *Message) In(lang Lang) string {
+	return T(lang, m.ID, m.Args...)
+}
+
+// Error returns the message in Default, e.g. for logs.
+func (m *Message) Error() string {
+	return m.In(Default)
+}
+
+// Translate returns the text of err in lang: the translation of the Message it is or wraps,
+// otherwise err's own text.
+func Translate(lang Lang, err error) string {
+	var m *Message
+	if errors.As(err, &m) {
+		return m.In(lang)
+	}
+	return err.Error()
+}
@@ -0,0 +1,361 @@
+package i18n
+
+// catalog holds every message by ID, in every language. Arguments follow fmt.Sprintf and come
+// in the same order in each translation.
+var catalog = map[string]map[Lang]string{
+	// Request errors
+	"route_not_found": {
+		Kyrgyz:  "Мындай API дареги жок",
+		Russian: "Такого адреса API нет",
+		English: "No such API endpoint",
+	},
+	"method_not_allowed": {
+		Kyrgyz:  "Бул ыкма колдоого алынбайт",
+		Russian: "Этот метод не поддерживается",
+		English: "Method not allowed",
+	},
+	"invalid_body": {
+		Kyrgyz:  "Суроонун мазмуну туура эмес",
+		Russian: "Неверное содержимое запроса",
+		English: "Invalid request body",
+	},
+	"invalid_field_type": {
+		Kyrgyz:  "Маанинин түрү туура эмес",
+		Russian: "Неверный тип значения",
+		English: "Wrong type of value",
+	},
+	"invalid_cursor": {
+		Kyrgyz:  "Барак курсору туура эмес",
+		Russian: "Неверный курсор страницы",
+		English: "Invalid page cursor",
+	},
+	"internal_error": {
+		Kyrgyz:  "Серверде ката кетти, кайра аракет кылыңыз",
+		Russian: "Ошибка на сервере, попробуйте ещё раз",
+		English: "Something went wrong on the server, please try again",
+	},
+	"param_required": {
+		Kyrgyz:  "'%s' милдеттүү",
+		Russian: "Параметр '%s' обязателен",
+		English: "'%s' is required",
+	},
+	"param_required_either": {
+		Kyrgyz:  "'%s' же '%s' милдеттүү",
+		Russian: "Нужен параметр '%s' или '%s'",
+		English: "'%s' or '%s' is required",
+	},
+	"invalid_id": {
+		Kyrgyz:  "'%s' туура эмес",
+		Russian: "Неверный '%s'",
+		English: "Invalid '%s'",
+	},
+	"invalid_date": {
+		Kyrgyz:  "'%s' күнү туура эмес: %q, ЖЖЖЖ-АА-КК түрүндө жазыңыз",
+		Russian: "Неверная дата '%s': %q, ожидается ГГГГ-ММ-ДД",
+		English: "Invalid '%s' date %q, expected YYYY-MM-DD",
+	},
+	"date_range_reversed": {
+		Kyrgyz:  "'%s' '%s' күнүнөн кийин болбошу керек",
+		Russian: "'%s' не может быть позже '%s'",
+		English: "'%s' must not be after '%s'",
+	},
+	"invalid_amount": {
+		Kyrgyz:  "'%s' туура эмес: %q, терс эмес сан болушу керек",
+		Russian: "Неверное значение '%s': %q, ожидается неотрицательное число",
+		English: "Invalid '%s' %q, expected a non-negative number",
+	},
+	"invalid_bool": {
+		Kyrgyz:  "'%s' туура эмес: %q, true же false болушу керек",
+		Russian: "Неверное значение '%s': %q, ожидается true или false",
+		English: "Invalid '%s' %q, expected true or false",
+	},
+	"invalid_choice": {
+		Kyrgyz:  "'%s' мааниси туура эмес: %q, мүмкүн болгондору: %s",
+		Russian: "Недопустимое значение '%s': %q, возможные: %s",
+		English: "Invalid '%s' %q, expected one of: %s",
+	},
+	"min_above_max": {
+		Kyrgyz:  "'%s' '%s' маанисинен чоң болбошу керек",
+		Russian: "'%s' не может быть больше '%s'",
+		English: "'%s' must not be greater than '%s'",
+	},
+	"invalid_time_zone": {
+		Kyrgyz:  "Убакыт алкагы туура эмес: %q",
+		Russian: "Неверный часовой пояс: %q",
+		English: "Invalid time zone %q",
+	},
+	"invalid_language": {
+		Kyrgyz:  "Тил туура эмес: %q, мүмкүн болгондору: ky, ru, en",
+		Russian: "Неверный язык: %q, возможные: ky, ru, en",
+		English: "Invalid language %q, expected one of: ky, ru, en",
+	},
+
+	// Not found
+	"debt_not_found": {
+		Kyrgyz:  "Карыз табылган жок",
+		Russian: "Долг не найден",
+		English: "Debt not found",
+	},
+	"client_not_found": {
+		Kyrgyz:  "Клиент табылган жок",
+		Russian: "Клиент не найден",
+		English: "Client not found",
+	},
+	"phone_not_found": {
+		Kyrgyz:  "Номер табылган жок",
+		Russian: "Номер не найден",
+		English: "Phone number not found",
+	},
+
+	// Conflicts
+	"debt_not_active": {
+		Kyrgyz:  "Карыз жабылган же өчүрүлгөн",
+		Russian: "Долг уже закрыт или удалён",
+		English: "The debt is already closed or deleted",
+	},
+	"debt_not_deleted": {
+		Kyrgyz:  "Карыз өчүрүлгөн эмес",
+		Russian: "Долг не удалён",
+		English: "The debt is not deleted",
+	},
+	"debt_changed": {
+		Kyrgyz:  "Карыз ушул арада өзгөрүлдү, кайра аракет кылыңыз",
+		Russian: "Долг за это время изменился, попробуйте ещё раз",
+		English: "The debt was changed in the meantime, please try again",
+	},
+	"client_has_debts": {
+		Kyrgyz:  "Клиенттин карыздары бар, аны өчүрүүгө болбойт",
+		Russian: "У клиента есть долги, его нельзя удалить",
+		English: "The client has debts and cannot be deleted",
+	},
+	"phone_taken": {
+		Kyrgyz:  "Бул номер башка клиентке таандык",
+		Russian: "Этот номер принадлежит другому клиенту",
+		English: "This number belongs to another client",
+	},
+	"main_phone": {
+		Kyrgyz:  "Негизги номерди өчүрүүгө болбойт",
+		Russian: "Основной номер удалить нельзя",
+		English: "The main number cannot be removed",
+	},
+	"owner_pin_required": {
+		Kyrgyz:  "Бул үчүн ээсинин PIN коду керек",
+		Russian: "Для этого нужен PIN-код владельца",
+		English: "The owner's PIN is required for this",
+	},
+
+	// Credit refusals, by models.CreditReason...
+	"credit_limit_exceeded": {
+		Kyrgyz:  "Карыз клиенттин кредит лимитинен ашып кетет",
+		Russian: "Долг превысит кредитный лимит клиента",
+		English: "The debt would exceed the client's credit limit",
+	},
+	"reputation_blocked": {
+		Kyrgyz:  "Клиенттин репутациясы боюнча жаңы карыз берилбейт",
+		Russian: "По репутации клиента новый долг не выдаётся",
+		English: "The client's reputation does not allow new debts",
+	},
+	"client_blocked": {
+		Kyrgyz:  "Бул клиентке карыз берүү токтотулган",
+		Russian: "Выдача в долг этому клиенту остановлена",
+		English: "Credit to this client is stopped",
+	},
+
+	// Validation
+	"invalid_phone": {
+		Kyrgyz:  "Телефон номери туура эмес",
+		Russian: "Неверный номер телефона",
+		English: "Invalid phone number",
+	},
+	"amount_not_positive": {
+		Kyrgyz:  "Сумма нөлдөн чоң болушу керек",
+		Russian: "Сумма должна быть больше нуля",
+		English: "The amount must be greater than zero",
+	},
+	"photo_required": {
+		Kyrgyz:  "Жаңы клиент үчүн сүрөт милдеттүү",
+		Russian: "Для нового клиента фото обязательно",
+		English: "A photo is required for a new client",
+	},
+	"due_date_invalid": {
+		Kyrgyz:  "Төлөө мөөнөтү туура эмес, ЖЖЖЖ-АА-КК түрүндө жазыңыз",
+		Russian: "Неверный срок оплаты, ожидается ГГГГ-ММ-ДД",
+		English: "Invalid due date, expected YYYY-MM-DD",
+	},
+	"guarantor_is_borrower": {
+		Kyrgyz:  "Карыз алуучу өзүнө кепил боло албайт",
+		Russian: "Заёмщик не может быть своим поручителем",
+		English: "The borrower cannot be their own guarantor",
+	},
+	"guarantor_not_found": {
+		Kyrgyz:  "Кепил табылган жок",
+		Russian: "Поручитель не найден",
+		English: "Guarantor not found",
+	},
+	"comment_required": {
+		Kyrgyz:  "Комментарий милдеттүү",
+		Russian: "Комментарий обязателен",
+		English: "A comment is required",
+	},
+	"delete_reason_required": {
+		Kyrgyz:  "Өчүрүү себеби (комментарий) милдеттүү",
+		Russian: "Причина удаления (комментарий) обязательна",
+		English: "A reason (comment) is required to delete",
+	},
+	"invalid_rating": {
+		Kyrgyz:  "Баа туура эмес",
+		Russian: "Неверная оценка",
+		English: "Invalid rating",
+	},
+	"credit_limit_negative": {
+		Kyrgyz:  "Кредит лимити терс болбошу керек",
+		Russian: "Кредитный лимит не может быть отрицательным",
+		English: "The credit limit must not be negative",
+	},
+	"block_reason_required": {
+		Kyrgyz:  "Бөгөттөө себеби милдеттүү",
+		Russian: "Причина блокировки обязательна",
+		English: "A reason is required to block a client",
+	},
+
+	// Settings
+	"unknown_setting": {
+		Kyrgyz:  "Белгисиз жөндөө: %s",
+		Russian: "Неизвестная настройка: %s",
+		English: "Unknown setting: %s",
+	},
+	"invalid_setting": {
+		Kyrgyz:  "%s жөндөөсүнүн мааниси туура эмес: %s",
+		Russian: "Недопустимое значение настройки %s: %s",
+		English: "Invalid value for %s: %s",
+	},
+	"invalid_aging_bucket": {
+		Kyrgyz:  "Мөөнөт чеги туура эмес: %q, оң сандагы күн болушу керек",
+		Russian: "Неверная граница срока: %q, ожидается положительное число дней",
+		English: "Invalid aging bucket %q: must be a positive number of days",
+	},
+	"aging_buckets_order": {
+		Kyrgyz:  "Мөөнөт чектери өсүү тартибинде болушу керек",
+		Russian: "Границы сроков должны идти по возрастанию",
+		English: "Aging buckets must be in increasing order",
+	},
+	"aging_buckets_empty": {
+		Kyrgyz:  "Жок дегенде бир мөөнөт чеги керек",
+		Russian: "Нужна хотя бы одна граница срока",
+		English: "At least one aging bucket is required",
+	},
+	"invalid_reputation_weights": {
+		Kyrgyz:  "Репутациянын салмактары туура эмес: %v",
+		Russian: "Неверные веса репутации: %v",
+		English: "Invalid reputation weights: %v",
+	},
+	"reputation_weights_not_positive": {
+		Kyrgyz:  "half_life_days жана amount_scale оң сан болушу керек",
+		Russian: "half_life_days и amount_scale должны быть положительными",
+		English: "half_life_days and amount_scale must be positive",
+	},
+	"reputation_thresholds_reversed": {
+		Kyrgyz:  "bad_threshold good_threshold маанисинен жогору болбошу керек",
+		Russian: "bad_threshold не может быть выше good_threshold",
+		English: "bad_threshold must not be above good_threshold",
+	},
+	"invalid_term_days": {
+		Kyrgyz:  "Мөөнөт оң сандагы күн болушу керек",
+		Russian: "Срок должен быть положительным числом дней",
+		English: "The term must be a positive number of days",
+	},
+	"invalid_credit_limit": {
+		Kyrgyz:  "Кредит лимити терс эмес сумма болушу керек",
+		Russian: "Кредитный лимит должен быть неотрицательной суммой",
+		English: "The credit limit must be a non-negative amount",
+	},
+	"unknown_reputation": {
+		Kyrgyz:  "Белгисиз репутация: %q",
+		Russian: "Неизвестная репутация: %q",
+		English: "Unknown reputation %q",
+	},
+	"invalid_retention_period": {
+		Kyrgyz:  "Сактоо мөөнөтү бүтүн сан болушу керек, 0 болсо түбөлүк сакталат",
+		Russian: "Срок хранения должен быть целым числом, 0 — хранить всегда",
+		English: "The retention period must be a whole number, 0 to keep forever",
+	},
+
+	// Successful changes
+	"debt_added": {
+		Kyrgyz:  "Карыз ийгиликтүү кошулду",
+		Russian: "Долг успешно добавлен",
+		English: "Debt added successfully",
+	},
+	"payment_made": {
+		Kyrgyz:  "Төлөм ийгиликтүү кабыл алынды",
+		Russian: "Платёж успешно принят",
+		English: "Payment made successfully",
+	},
+	"debt_deleted": {
+		Kyrgyz:  "Карыз ийгиликтүү өчүрүлдү",
+		Russian: "Долг успешно удалён",
+		English: "Debt deleted successfully",
+	},
+	"debt_restored": {
+		Kyrgyz:  "Карыз калыбына келтирилди",
+		Russian: "Долг восстановлен",
+		English: "Debt restored successfully",
+	},
+	"credit_limit_saved": {
+		Kyrgyz:  "Кредит лимити сакталды",
+		Russian: "Кредитный лимит сохранён",
+		English: "Credit limit saved successfully",
+	},
+	"client_updated": {
+		Kyrgyz:  "Клиент ийгиликтүү жаңыртылды",
+		Russian: "Клиент успешно обновлён",
+		English: "Client updated successfully",
+	},
+	"client_removed": {
+		Kyrgyz:  "Клиент ийгиликтүү өчүрүлдү",
+		Russian: "Клиент успешно удалён",
+		English: "Client removed successfully",
+	},
+	"phone_added": {
+		Kyrgyz:  "Номер ийгиликтүү кошулду",
+		Russian: "Номер успешно добавлен",
+		English: "Phone added successfully",
+	},
+	"phone_deleted": {
+		Kyrgyz:  "Номер ийгиликтүү өчүрүлдү",
+		Russian: "Номер успешно удалён",
+		English: "Phone deleted successfully",
+	},
+	"settings_saved": {
+		Kyrgyz:  "Жөндөөлөр сакталды",
+		Russian: "Настройки сохранены",
+		English: "Settings saved successfully",
+	},
+
+	// Exports
+	"export_client_id": {
+		Kyrgyz:  "Клиенттин ID",
+		Russian: "ID клиента",
+		English: "Client ID",
+	},
+	"export_fullname": {
+		Kyrgyz:  "Аты-жөнү",
+		Russian: "ФИО",
+		English: "Full name",
+	},
+	"export_phone": {
+		Kyrgyz:  "Телефон",
+		Russian: "Телефон",
+		English: "Phone",
+	},
+	"export_days": {
+		Kyrgyz:  "%s күн",
+		Russian: "%s дн.",
+		English: "%s days",
+	},
+	"export_total": {
+		Kyrgyz:  "Жалпы",
+		Russian: "Итого",
+		English: "Total",
+	},
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"debtNote/database"
 	"debtNote/handlers"
+	"debtNote/repository"
+	"debtNote/service"
 	"embed"
 	"fmt"
 	"io"
@@ -14,6 +17,7 @@ import (
 	"runtime"
 	"strings"
 	"time"
+	_ "time/tzdata" // Time zone database for report time zones on systems without one (Windows)
 )
 
 //go:embed static/*
@@ -21,8 +25,29 @@ var staticFiles embed.FS
 
 func main() {
 	// Initialize database
-	database.InitDB()
-	defer database.DB.Close()
+	db := database.Open(database.DefaultPath)
+	defer db.Close()
+	store := repository.NewSQLite(db)
+	retentionService := &service.Retention{Debts: store, Settings: store}
+
+	// Command line tools run instead of the server, e.g. "debtNote purge -dry-run"
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		code := runPurgeCommand(retentionService, os.Args[2:])
+		db.Close()
+		os.Exit(code)
+	}
+
+	srv := &handlers.Server{
+		Clients:  store,
+		Debts:    store,
+		Settings: store,
+		Audit:    store,
+		Reports:  store,
+
+		DebtService:      &service.Debts{Clients: store, Debts: store, Audit: store},
+		ClientService:    &service.Clients{Clients: store, Audit: store},
+		RetentionService: retentionService,
+	}
 
 	// Create uploads directory if it doesn't exist
 	if _, err := os.Stat("uploads"); os.IsNotExist(err) {
@@ -42,18 +67,62 @@ func main() {
 	// Serve uploaded files (Local file system)
 	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("uploads"))))
 
-	// API routes
-	http.HandleFunc("/api/clients", handlers.GetClientsHandler)
-	http.HandleFunc("/api/clients/search", handlers.SearchClientsHandler)
-	http.HandleFunc("/api/debts", handlers.GetDebtsHandler)
-	http.HandleFunc("/api/debts/add", handlers.AddDebtHandler)
-	http.HandleFunc("/api/debts/pay", handlers.MakePaymentHandler)
-	http.HandleFunc("/api/debts/payments", handlers.GetDebtPaymentsHandler)
-	http.HandleFunc("/api/debts/delete", handlers.DeleteDebtHandler)
+	// API routes. Resource routes carry IDs in the path; the older action routes
+	// (e.g. "POST /api/debts/pay") stay as aliases until every client has moved over.
+	http.HandleFunc("GET /api/clients", srv.GetClientsHandler)
+	http.HandleFunc("GET /api/clients/search", srv.SearchClientsHandler)
+	http.HandleFunc("GET /api/clients/duplicates", srv.GetDuplicateClientsHandler)
+	http.HandleFunc("GET /api/clients/{id}", srv.GetClientHandler)
+	http.HandleFunc("DELETE /api/clients/{id}", srv.DeleteClientHandler)
+	http.HandleFunc("PUT /api/clients/{id}/credit-limit", srv.SetCreditLimitHandler)
+	http.HandleFunc("PUT /api/clients/{id}/block", srv.SetClientBlockedHandler)
+	http.HandleFunc("GET /api/clients/{id}/export", srv.ExportClientHandler)
+	http.HandleFunc("GET /api/clients/{id}/guarantees", srv.GetGuarantorStatementHandler)
+	http.HandleFunc("GET /api/clients/{id}/phones", srv.GetClientPhonesHandler)
+	http.HandleFunc("POST /api/clients/{id}/phones", srv.AddClientPhoneHandler)
+	http.HandleFunc("DELETE /api/clients/{id}/phones/{phone}", srv.DeleteClientPhoneHandler)
+	http.HandleFunc("GET /api/debts", srv.GetDebtsHandler)
+	http.HandleFunc("POST /api/debts", srv.AddDebtHandler)
+	http.HandleFunc("GET /api/debts/{id}", srv.GetDebtHandler)
+	http.HandleFunc("DELETE /api/debts/{id}", srv.DeleteDebtHandler)
+	http.HandleFunc("GET /api/debts/{id}/payments", srv.GetDebtPaymentsHandler)
+	http.HandleFunc("POST /api/debts/{id}/payments", srv.MakePaymentHandler)
+	http.HandleFunc("POST /api/debts/{id}/restore", srv.RestoreDebtHandler)
+	http.HandleFunc("GET /api/audit", srv.GetAuditEntriesHandler)
+	http.HandleFunc("GET /api/stats", srv.GetStatsHandler)
+	http.HandleFunc("GET /api/reports/aging", srv.GetAgingReportHandler)
+	http.HandleFunc("GET /api/reports/timeseries", srv.GetTimeSeriesHandler)
+	http.HandleFunc("GET /api/reports/risk", srv.GetClientRisksHandler)
+	http.HandleFunc("GET /api/settings", srv.GetSettingsHandler)
+	http.HandleFunc("PUT /api/settings", srv.UpdateSettingsHandler)
+	http.HandleFunc("GET /api/retention", srv.RetentionHandler)
+	http.HandleFunc("POST /api/retention", srv.RetentionHandler)
+
+	// Old action routes
+	http.HandleFunc("POST /api/clients/credit-limit", srv.SetCreditLimitHandler)
+	http.HandleFunc("POST /api/clients/block", srv.SetClientBlockedHandler)
+	http.HandleFunc("POST /api/clients/delete", srv.DeleteClientHandler)
+	http.HandleFunc("GET /api/clients/export", srv.ExportClientHandler)
+	http.HandleFunc("GET /api/clients/guarantees", srv.GetGuarantorStatementHandler)
+	http.HandleFunc("GET /api/clients/phones", srv.GetClientPhonesHandler)
+	http.HandleFunc("POST /api/clients/phones/add", srv.AddClientPhoneHandler)
+	http.HandleFunc("POST /api/clients/phones/delete", srv.DeleteClientPhoneHandler)
+	http.HandleFunc("POST /api/debts/add", srv.AddDebtHandler)
+	http.HandleFunc("POST /api/debts/pay", srv.MakePaymentHandler)
+	http.HandleFunc("GET /api/debts/payments", srv.GetDebtPaymentsHandler)
+	http.HandleFunc("POST /api/debts/delete", srv.DeleteDebtHandler)
+	http.HandleFunc("POST /api/debts/restore", srv.RestoreDebtHandler)
+	http.HandleFunc("POST /api/settings/update", srv.UpdateSettingsHandler)
+
+	// Unknown API paths and methods get a JSON error rather than the app
+	http.Handle("/api/", handlers.NoRouteHandler(http.DefaultServeMux))
+
+	// Purge debts past the retention policy once a day
+	go retentionService.Schedule(context.Background(), 24*time.Hour)
 
 	// Handle SPA (Single Page Application) routing
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/uploads/") {
+		if strings.HasPrefix(r.URL.Path, "/uploads/") {
 			http.NotFound(w, r)
 			return
 		}
@@ -79,7 +148,7 @@ func main() {
 	}()
 
 	// Fix: Use '=' instead of ':=' because err is already declared
-	err = http.ListenAndServe(addr, nil)
+	err = http.ListenAndServe(addr, srv.Localize(handlers.GuardOldRoutes(http.DefaultServeMux)))
 	if err != nil {
 		log.Fatal("Server failed to start:", err)
 	}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Audited entities.
+const (
+	AuditEntityClient    = "client"
+	AuditEntityDebt      = "debt"
+	AuditEntityRetention = "retention" // A purge; EntityID is 0
+)
+
+// AuditEntry records a sensitive action, such as an owner override, for later review.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Entity    string    `json:"entity"`    // 'client', 'debt' or 'retention'
+	EntityID  int64     `json:"entity_id"` // ID of the client or debt
+	Action    string    `json:"action"`
+	Details   string    `json:"details"` // Free text or JSON with the specifics
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -2,22 +2,54 @@ package models
 
 import "time"
 
+// AnonymizedClientName replaces the name of a client whose personal data was erased.
+const AnonymizedClientName = "Өчүрүлгөн клиент"
+
 type Client struct {
-	ID        int64     `json:"id"`
-	Fullname  string    `json:"fullname"`
-	Phone     string    `json:"phone"`
-	Address   string    `json:"address"`
-	PhotoData string    `json:"photo_data"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int64      `json:"id"`
+	Fullname      string     `json:"fullname"`
+	Phone         string     `json:"phone"`
+	Address       string     `json:"address"`
+	PhotoData     string     `json:"photo_data"`
+	CreditLimit   *float64   `json:"credit_limit"` // Max total outstanding; nil uses the shop default
+	Blocked       bool       `json:"blocked"`      // Stop-credit flag: no new debts without owner override
+	BlockedReason string     `json:"blocked_reason,omitempty"`
+	BlockedAt     *time.Time `json:"blocked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ClientListItem is a client in the client list, with what they owe and when they were last active.
+type ClientListItem struct {
+	Client
+	Outstanding        float64    `json:"outstanding"`           // Total of active debts
+	ActiveDebts        int        `json:"active_debts"`          // Number of active debts
+	LastPaymentAt      *time.Time `json:"last_payment_at"`       // Nil if they never paid
+	OldestActiveDebtAt *time.Time `json:"oldest_active_debt_at"` // Nil without active debts
+	LastActivityAt     time.Time  `json:"last_activity_at"`      // Latest of joining, borrowing and paying
+	Reputation         string     `json:"reputation"`            // 'untrusted', 'bad', 'good', or 'none'
+	ReputationScore    float64    `json:"reputation_score"`      // 0..100, 50 without history
 }
 
 // ClientSearchInfo represents a client in the search dropdown.
 type ClientSearchInfo struct {
-	ID            int64  `json:"id"`
-	Fullname      string `json:"fullname"`
-	Phone         string `json:"phone"`
-	Address       string `json:"address"`
-	PhotoData     string `json:"photo_data"`
-	HasActiveDebt bool   `json:"has_active_debt"`
-	Reputation    string `json:"reputation"` // 'untrusted', 'bad', 'good', or 'none'
+	ID                int64      `json:"id"`
+	Fullname          string     `json:"fullname"`
+	Phone             string     `json:"phone"`
+	Phones            []string   `json:"phones"` // All numbers, the main one first
+	Address           string     `json:"address"`
+	PhotoData         string     `json:"photo_data"`
+	HasActiveDebt     bool       `json:"has_active_debt"`
+	Reputation        string     `json:"reputation"`       // 'untrusted', 'bad', 'good', or 'none'
+	ReputationScore   float64    `json:"reputation_score"` // 0..100, 50 without history
+	Blocked           bool       `json:"blocked"`
+	BlockedReason     string     `json:"blocked_reason,omitempty"`
+	BlockedAt         *time.Time `json:"blocked_at,omitempty"`
+	GuaranteeExposure float64    `json:"guarantee_exposure"` // Open amount of other clients' debts they vouched for
+	GuaranteeOverdue  bool       `json:"guarantee_overdue"`  // One of those debts is overdue
+}
+
+// Reputation is a client's weighted repayment score and the label derived from it.
+type Reputation struct {
+	Score float64 `json:"score"` // 0..100, 50 without history
+	Label string  `json:"label"` // 'untrusted', 'bad', 'good', or 'none'
 }
@@ -0,0 +1,19 @@
+package models
+
+// Reasons a new debt can be refused.
+const (
+	CreditReasonLimitExceeded = "credit_limit_exceeded" // The debt would take the client over their limit
+	CreditReasonReputation    = "reputation_blocked"    // The client's reputation is not allowed new credit
+	CreditReasonClientBlocked = "client_blocked"        // The client is on the stop-credit list
+)
+
+// CreditCheck is the outcome of checking whether a client may take a new debt.
+type CreditCheck struct {
+	Allowed       bool     `json:"allowed"`
+	Reason        string   `json:"reason,omitempty"`
+	Limit         *float64 `json:"limit"` // nil means no limit
+	Outstanding   float64  `json:"outstanding"`
+	Requested     float64  `json:"requested"`
+	Reputation    string   `json:"reputation"`
+	BlockedReason string   `json:"blocked_reason,omitempty"`
+}
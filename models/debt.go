@@ -31,6 +31,7 @@ type Debt struct {
 	PaidAt        *time.Time `json:"paid_at,omitempty"`        // Time when the debt was paid
 	DeletedAt     *time.Time `json:"deleted_at,omitempty"`     // Time when the debt was deleted
 	DeleteComment string     `json:"delete_comment,omitempty"` // Reason for deletion
+	DueDate       *time.Time `json:"due_date,omitempty"`       // Date the debt should be repaid by (optional)
 }
 
 // DebtPayment represents a partial or full payment record.
@@ -42,3 +43,24 @@ type DebtPayment struct {
 	Comment         string    `json:"comment"`
 	CreatedAt       time.Time `json:"created_at"`
 }
+
+// Guarantee is a debt of another client that a guarantor vouched for.
+type Guarantee struct {
+	DebtID        int64      `json:"debt_id"`
+	BorrowerID    int64      `json:"borrower_id"`
+	BorrowerName  string     `json:"borrower_name"`
+	BorrowerPhone string     `json:"borrower_phone"`
+	Amount        float64    `json:"amount"` // Remaining amount
+	Status        DebtStatus `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DueDate       *time.Time `json:"due_date"`
+	Overdue       bool       `json:"overdue"`
+}
+
+// GuarantorStatement lists what a client has vouched for and how much of it is still open.
+type GuarantorStatement struct {
+	ClientID int64       `json:"client_id"`
+	Exposure float64     `json:"exposure"` // Remaining amount of active guaranteed debts
+	Overdue  bool        `json:"overdue"`  // At least one guaranteed debt is overdue
+	Debts    []Guarantee `json:"debts"`
+}
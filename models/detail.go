@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// DebtDetail is everything about one debt, for its own page.
+type DebtDetail struct {
+	Debt
+	OriginalAmount float64       `json:"original_amount"` // Amount lent; Amount is what is still owed
+	PaidAmount     float64       `json:"paid_amount"`     // Total of the payments
+	Client         Client        `json:"client"`
+	Payments       []DebtPayment `json:"payments"` // Newest first
+	Schedule       DebtSchedule  `json:"schedule"`
+	Audit          AuditSummary  `json:"audit"`
+}
+
+// DebtSchedule is when a debt has to be repaid.
+type DebtSchedule struct {
+	DueDate     time.Time `json:"due_date"`     // The debt's own due date, or the default term after it was given
+	DefaultTerm bool      `json:"default_term"` // DueDate comes from the default term
+	TermDays    int       `json:"term_days"`    // Days from giving the debt to DueDate
+	Overdue     bool      `json:"overdue"`      // Still active after DueDate
+	DaysOverdue int       `json:"days_overdue"` // 0 unless Overdue
+}
+
+// ClientDetail is everything about one client, for their own page.
+type ClientDetail struct {
+	Client
+	Phones            []string      `json:"phones"` // All numbers, the main one first
+	Balance           ClientBalance `json:"balance"`
+	Reputation        Reputation    `json:"reputation"`
+	Credit            CreditCheck   `json:"credit"`             // Whether they can be given a new debt now
+	GuaranteeExposure float64       `json:"guarantee_exposure"` // Open amount of other clients' debts they vouched for
+	GuaranteeOverdue  bool          `json:"guarantee_overdue"`  // One of those debts is overdue
+	Audit             AuditSummary  `json:"audit"`
+}
+
+// ClientBalance sums up a client's debts. Deleted debts only count in DeletedDebts.
+type ClientBalance struct {
+	Outstanding  float64 `json:"outstanding"` // Still owed on active debts
+	Overdue      float64 `json:"overdue"`     // Part of Outstanding past its due date
+	Borrowed     float64 `json:"borrowed"`    // Total lent
+	Paid         float64 `json:"paid"`        // Total of the payments
+	ActiveDebts  int     `json:"active_debts"`
+	PaidDebts    int     `json:"paid_debts"`
+	DeletedDebts int     `json:"deleted_debts"`
+}
+
+// AuditSummary sums up the audit log of a debt or client.
+type AuditSummary struct {
+	Entries int            `json:"entries"`
+	Actions map[string]int `json:"actions"` // Number of entries per action
+	Last    *AuditEntry    `json:"last"`    // The latest entry, nil without any
+}
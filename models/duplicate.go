@@ -0,0 +1,19 @@
+package models
+
+// DuplicateReason explains why an existing client looks like the one being added.
+type DuplicateReason struct {
+	Field    string `json:"field"`    // 'name' or 'phone'
+	Value    string `json:"value"`    // The existing client's name or number that matched
+	Distance int    `json:"distance"` // Edit distance after normalization; 0 means identical
+}
+
+// DuplicateCandidate is an existing client that may be the same person as a new entry.
+type DuplicateCandidate struct {
+	ClientID  int64             `json:"client_id"`
+	Fullname  string            `json:"fullname"`
+	Phone     string            `json:"phone"`
+	Address   string            `json:"address"`
+	PhotoData string            `json:"photo_data"`
+	Score     float64           `json:"score"` // 0..1, higher is more likely the same person
+	Reasons   []DuplicateReason `json:"reasons"`
+}
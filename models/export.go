@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ClientExport is everything stored about one client, handed over when they ask for their data.
+type ClientExport struct {
+	ExportedAt time.Time     `json:"exported_at"`
+	Client     Client        `json:"client"`
+	Phones     []string      `json:"phones"`
+	Debts      []Debt        `json:"debts"`    // All statuses, deleted ones included
+	Payments   []DebtPayment `json:"payments"` // Payments on those debts
+	Audit      []AuditEntry  `json:"audit"`    // Entries about the client and their debts
+}
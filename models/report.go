@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// AgingBucket describes one age range of the aging report. MaxDays is nil for the last, open-ended bucket.
+type AgingBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays *int   `json:"max_days"`
+}
+
+// AgingRow holds one client's active balance split by age bucket.
+type AgingRow struct {
+	ClientID int64     `json:"client_id"`
+	Fullname string    `json:"fullname"`
+	Phone    string    `json:"phone"`
+	Amounts  []float64 `json:"amounts"` // Same order as AgingReport.Buckets
+	Total    float64   `json:"total"`
+}
+
+// AgingReport groups active balances by how long they have been outstanding.
+type AgingReport struct {
+	AsOf       time.Time     `json:"as_of"`
+	Buckets    []AgingBucket `json:"buckets"`
+	Rows       []AgingRow    `json:"rows"`
+	Totals     []float64     `json:"totals"`
+	GrandTotal float64       `json:"grand_total"`
+}
+
+// TimeSeriesPoint holds credit issued and collected in one day, week or month.
+type TimeSeriesPoint struct {
+	Start       time.Time `json:"start"`
+	Issued      float64   `json:"issued"`
+	Collected   float64   `json:"collected"`
+	Net         float64   `json:"net"`         // Issued minus collected: positive means receivables grew
+	Outstanding float64   `json:"outstanding"` // Cumulative outstanding at the end of the bucket
+}
+
+// TimeSeries is a bucketed history of credit issued against money collected.
+type TimeSeries struct {
+	Interval string            `json:"interval"`
+	TimeZone string            `json:"time_zone"`
+	Opening  float64           `json:"opening"` // Outstanding before the first bucket
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+// ClientRisk ranks a client by how risky it is to give them more credit.
+type ClientRisk struct {
+	ClientID        int64   `json:"client_id"`
+	Fullname        string  `json:"fullname"`
+	Phone           string  `json:"phone"`
+	PhotoData       string  `json:"photo_data"`
+	Outstanding     float64 `json:"outstanding"`
+	ActiveDebts     int     `json:"active_debts"`
+	OldestDays      int     `json:"oldest_days"` // Age of the oldest unpaid debt, 0 without active debts
+	Reputation      string  `json:"reputation"`
+	ReputationScore float64 `json:"reputation_score"`
+	RiskScore       float64 `json:"risk_score"` // 0 (safe) .. 100 (stop giving credit)
+}
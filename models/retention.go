@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ArchivedDebt is a purged debt as saved in the retention archive, with the rows deleted along with it.
+type ArchivedDebt struct {
+	Debt         Debt          `json:"debt"`
+	Payments     []DebtPayment `json:"payments"`
+	GuarantorIDs []int64       `json:"guarantor_ids"`
+}
+
+// PurgeReport describes a retention run, or what a dry run would remove.
+type PurgeReport struct {
+	DryRun        bool       `json:"dry_run"`
+	DeletedBefore *time.Time `json:"deleted_before,omitempty"` // Deleted debts older than this are purged; nil when they are kept
+	PaidBefore    *time.Time `json:"paid_before,omitempty"`    // Paid debts older than this are purged; nil when they are kept
+	DeletedDebts  int        `json:"deleted_debts"`
+	PaidDebts     int        `json:"paid_debts"`
+	Payments      int        `json:"payments"`
+	Archive       string     `json:"archive,omitempty"` // Compressed JSON file holding the purged rows
+}
@@ -0,0 +1,35 @@
+package models
+
+// Setting keys stored in the settings table.
+const (
+	SettingAgingBuckets = "aging_buckets" // Upper bounds (days) of aging buckets, e.g. "30,60,90"
+	SettingTimeZone     = "timezone"      // IANA time zone for reports, e.g. "Asia/Bishkek"; empty means server time
+
+	SettingReputationWeights = "reputation_weights" // JSON weights of the reputation score, see repository.ReputationWeights
+	SettingDefaultTermDays   = "default_term_days"  // Days to repay a debt that has no due date
+
+	SettingDefaultCreditLimit = "default_credit_limit" // Max total outstanding per client without its own limit; 0 means no credit, empty no limit
+	SettingBlockedReputations = "blocked_reputations"  // Comma separated reputation labels refused new credit, e.g. "untrusted"
+
+	SettingRetentionDeletedMonths = "retention_deleted_months" // Purge deleted debts this many months after deletion; 0 keeps them
+	SettingRetentionPaidYears     = "retention_paid_years"     // Purge paid debts this many years after payment; 0 keeps them
+
+	SettingLanguage = "language" // Language of API messages and exports: "ky", "ru" or "en"; empty follows the browser's
+)
+
+// DefaultSettings holds the value used for each setting until the shop changes it.
+var DefaultSettings = map[string]string{
+	SettingAgingBuckets: "30,60,90",
+	SettingTimeZone:     "",
+
+	SettingReputationWeights: "",
+	SettingDefaultTermDays:   "30",
+
+	SettingDefaultCreditLimit: "",
+	SettingBlockedReputations: "untrusted",
+
+	SettingRetentionDeletedMonths: "0",
+	SettingRetentionPaidYears:     "0",
+
+	SettingLanguage: "",
+}
@@ -0,0 +1,28 @@
+package models
+
+// FlowStats holds the money that went out as credit and came back as payments in a period.
+type FlowStats struct {
+	Issued    float64 `json:"issued"`    // Берилген карыз
+	Collected float64 `json:"collected"` // Кайтарылган акча
+}
+
+// RatingShares holds the share (0..1) of paid debts closed with each rating.
+type RatingShares struct {
+	Good      float64 `json:"good"`
+	Bad       float64 `json:"bad"`
+	Untrusted float64 `json:"untrusted"`
+}
+
+// DashboardStats is the overview shown on the dashboard.
+// Outstanding and debtor counts are a snapshot of now; the period figures follow the requested date range.
+type DashboardStats struct {
+	TotalOutstanding float64      `json:"total_outstanding"`
+	ActiveDebtors    int          `json:"active_debtors"`
+	Today            FlowStats    `json:"today"`
+	Week             FlowStats    `json:"week"`
+	Month            FlowStats    `json:"month"`
+	Period           FlowStats    `json:"period"`
+	AvgDaysToRepay   float64      `json:"avg_days_to_repay"`
+	RatedDebts       int          `json:"rated_debts"`
+	RatingShares     RatingShares `json:"rating_shares"`
+}
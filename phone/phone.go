@@ -0,0 +1,81 @@
+// Package phone normalizes phone numbers so the same number typed in different ways matches.
+package phone
+
+import (
+	"debtNote/i18n"
+	"strings"
+	"unicode"
+)
+
+// DefaultCountryCode is assumed for numbers typed without one (Kyrgyzstan).
+const DefaultCountryCode = "996"
+
+// localLength is the length of a Kyrgyz number without country code or trunk prefix (555 123 456).
+const localLength = 9
+
+// ErrInvalid is returned for input that cannot be a phone number.
+var ErrInvalid = i18n.New("invalid_phone")
+
+// Normalize converts a phone number to E.164 ("+996555123456").
+// It accepts spaces, dashes and brackets, the local form with a leading 0 ("0555 123 456"),
+// the bare 9 digits ("555123456"), and international forms with "+", "00" or the country code.
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	digits := Digits(raw)
+
+	var e164 string
+	switch {
+	case strings.HasPrefix(raw, "+"):
+		e164 = "+" + digits
+	case strings.HasPrefix(digits, "00"):
+		e164 = "+" + digits[2:]
+	case len(digits) == localLength:
+		e164 = "+" + DefaultCountryCode + digits
+	case len(digits) == localLength+1 && digits[0] == '0':
+		e164 = "+" + DefaultCountryCode + digits[1:]
+	case len(digits) == len(DefaultCountryCode)+localLength && strings.HasPrefix(digits, DefaultCountryCode):
+		e164 = "+" + digits
+	default:
+		return "", ErrInvalid
+	}
+
+	// E.164 allows at most 15 digits; anything under 8 is not a real number
+	if n := len(e164) - 1; n < 8 || n > 15 {
+		return "", ErrInvalid
+	}
+	return e164, nil
+}
+
+// Digits strips everything but digits from s.
+func Digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) && r < unicode.MaxASCII {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// minSearchDigits is how many digits a search needs before it is taken for a number.
+const minSearchDigits = 3
+
+// SearchDigits reports whether a search looks like a partial phone number: digits and nothing
+// else but spaces and + - ( ), at least minSearchDigits of them. If so, it returns the digits to
+// match inside normalized numbers, without the local trunk prefix 0: "0555 12" finds "+99655512...".
+func SearchDigits(query string) (string, bool) {
+	digits := Digits(query)
+	rest := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("+-() ", r) {
+			return -1
+		}
+		return r
+	}, query)
+	if len(digits) < minSearchDigits || rest != digits {
+		return "", false
+	}
+
+	if strings.HasPrefix(digits, "00") {
+		return digits[2:], true
+	}
+	return strings.TrimPrefix(digits, "0"), true
+}
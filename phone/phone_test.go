@@ -0,0 +1,71 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"+996555123456", "+996555123456"},
+		{"+996 (555) 12-34-56", "+996555123456"},
+		{"  0555 123 456 ", "+996555123456"},
+		{"555123456", "+996555123456"},
+		{"996555123456", "+996555123456"},
+		{"00996555123456", "+996555123456"},
+		{"+7 701 123 45 67", "+77011234567"},
+		{"0044 20 7946 0958", "+442079460958"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"abc",
+		"12345",             // Too short
+		"+1234567",          // Under 8 digits
+		"+1234567890123456", // Over 15 digits
+		"5551234567",        // 10 digits without the trunk 0
+		"1996555123456",     // Country code in the wrong place
+	} {
+		if got, err := Normalize(raw); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Normalize(%q) = %q, %v; want ErrInvalid", raw, got, err)
+		}
+	}
+}
+
+func TestSearchDigits(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"0555 12", "55512"},
+		{"+996 555", "996555"},
+		{"00996", "996"},
+		{"555-12", "55512"},
+		{"(0555) 12-34", "5551234"},
+		{"123", "123"},
+	}
+	for _, tt := range tests {
+		if got, ok := SearchDigits(tt.query); !ok || got != tt.want {
+			t.Errorf("SearchDigits(%q) = %q, %v; want %q", tt.query, got, ok, tt.want)
+		}
+	}
+}
+
+func TestSearchDigitsNotPhone(t *testing.T) {
+	for _, query := range []string{"Асан", "Асан 2", "нан 500", "55", "0555 x", "12.50", ""} {
+		if got, ok := SearchDigits(query); ok {
+			t.Errorf("SearchDigits(%q) = %q; want it not taken for a number", query, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"debtNote/service"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runPurgeCommand implements "debtNote purge": apply the retention policy once and print what was removed.
+// The periods default to the shop settings. It returns the process exit code.
+func runPurgeCommand(retention *service.Retention, args []string) int {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only show what would be purged")
+	deletedMonths := flags.Int("deleted-months", -1, "purge deleted debts older than this many months (0 keeps them; default from settings)")
+	paidYears := flags.Int("paid-years", -1, "purge paid debts older than this many years (0 keeps them; default from settings)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx := context.Background()
+	policy, err := retention.Policy(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load retention policy:", err)
+		return 1
+	}
+	if *deletedMonths >= 0 {
+		policy.DeletedMonths = *deletedMonths
+	}
+	if *paidYears >= 0 {
+		policy.PaidYears = *paidYears
+	}
+	if !policy.Enabled() {
+		fmt.Println("Retention policy keeps all debts, nothing to purge.")
+		return 0
+	}
+
+	report, err := retention.Run(ctx, policy, time.Now(), *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Purge failed:", err)
+		return 1
+	}
+
+	verb := "Purged"
+	if report.DryRun {
+		verb = "Would purge"
+	}
+	if report.DeletedBefore != nil {
+		fmt.Printf("%s %d deleted debts deleted before %s\n", verb, report.DeletedDebts, report.DeletedBefore.Local().Format("2006-01-02 15:04"))
+	}
+	if report.PaidBefore != nil {
+		fmt.Printf("%s %d paid debts paid before %s\n", verb, report.PaidDebts, report.PaidBefore.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("%s %d payments\n", verb, report.Payments)
+	if report.Archive != "" {
+		fmt.Println("Archived to", report.Archive)
+	}
+	return 0
+}
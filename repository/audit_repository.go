@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"strings"
+)
+
+// AddAuditEntry records an action in the audit log.
+func (s *SQLite) AddAuditEntry(ctx context.Context, entity string, entityID int64, action, details string) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO audit_log(entity, entity_id, action, details) VALUES(?, ?, ?, ?)",
+		entity, entityID, action, details)
+	return err
+}
+
+// GetAuditEntries retrieves the audit log of one client or debt, newest first.
+func (s *SQLite) GetAuditEntries(ctx context.Context, entity string, entityID int64) ([]models.AuditEntry, error) {
+	query := "SELECT id, entity, entity_id, action, COALESCE(details, ''), created_at FROM audit_log WHERE entity = ? AND entity_id = ? ORDER BY created_at DESC, id DESC"
+	rows, err := s.db.QueryContext(ctx, query, entity, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// auditBatchSize is how many entities EraseAuditDetails updates at once, well under SQLite's limit
+// on the number of query parameters.
+const auditBatchSize = 500
+
+// EraseAuditDetails blanks the details of the audit entries of the given clients or debts,
+// keeping what was done and when.
+func (s *SQLite) EraseAuditDetails(ctx context.Context, entity string, entityIDs []int64) error {
+	for start := 0; start < len(entityIDs); start += auditBatchSize {
+		batch := entityIDs[start:min(start+auditBatchSize, len(entityIDs))]
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := []interface{}{entity}
+		for _, id := range batch {
+			args = append(args, id)
+		}
+		query := "UPDATE audit_log SET details = '' WHERE entity = ? AND entity_id IN (" + placeholders + ")"
+		if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
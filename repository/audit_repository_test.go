@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"testing"
+)
+
+func TestEraseAuditDetails(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	for _, id := range []int64{1, 2, 3} {
+		if err := s.AddAuditEntry(ctx, models.AuditEntityDebt, id, "deleted", "reason"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.AddAuditEntry(ctx, models.AuditEntityClient, 1, "blocked", "reason"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.EraseAuditDetails(ctx, models.AuditEntityDebt, []int64{1, 3}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[int64]string{
+		models.AuditEntityDebt:   {1: "", 2: "reason", 3: ""},
+		models.AuditEntityClient: {1: "reason"},
+	}
+	for entity, details := range want {
+		for id, detail := range details {
+			entries, err := s.GetAuditEntries(ctx, entity, id)
+			if err != nil || len(entries) != 1 || entries[0].Action == "" || entries[0].Details != detail {
+				t.Errorf("%s %d: audit = %+v, %v; want details %q", entity, id, entries, err, detail)
+			}
+		}
+	}
+}
@@ -1,46 +1,310 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"debtNote/database"
+	"debtNote/i18n"
 	"debtNote/models"
-	"errors"
+	"debtNote/phone"
+	"debtNote/textnorm"
+	"fmt"
+	"strings"
+	"time"
 )
 
-// FindOrCreateClient finds a client by phone number or creates a new one.
-// It requires a photo for new clients.
-func FindOrCreateClient(client models.Client) (int64, error) {
-	// Check if client exists
+// ErrPhoneTaken is returned when a phone number already belongs to another client.
+var ErrPhoneTaken = i18n.New("phone_taken")
+
+// ErrMainPhone is returned when removing a client's main number, which has to stay.
+var ErrMainPhone = i18n.New("main_phone")
+
+// ErrClientHasDebts is returned when deleting a client who has debts or vouches for someone else's.
+var ErrClientHasDebts = i18n.New("client_has_debts")
+
+// phoneKey returns the form a number is stored in client_phones: E.164 when it normalizes,
+// otherwise as typed (numbers saved before normalization existed).
+func phoneKey(raw string) string {
+	if normalized, err := phone.Normalize(raw); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(raw)
+}
+
+// phoneSearchClause returns a condition matching the client whose id is in clientColumn by any of
+// their numbers, if the query looks like a phone number (see phone.SearchDigits). Such queries are
+// searched by number alone, so the digits of "Асан 2" never match a phone.
+func phoneSearchClause(query, clientColumn string) (string, []interface{}, bool) {
+	digits, ok := phone.SearchDigits(query)
+	if !ok {
+		return "", nil, false
+	}
+	clause := "EXISTS(SELECT 1 FROM client_phones cp WHERE cp.client_id = " + clientColumn + " AND cp.phone LIKE ?)"
+	return clause, []interface{}{"%" + digits + "%"}, true
+}
+
+// nameSearchClause returns a condition matching column against every spelling the query may stand for:
+// Cyrillic or Latin, or typed on the wrong keyboard layout.
+func nameSearchClause(query, column string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, variant := range textnorm.SearchVariants(query) {
+		conditions = append(conditions, "skeleton("+column+") LIKE ?")
+		args = append(args, "%"+variant+"%")
+	}
+	if len(conditions) == 0 {
+		return "0", nil
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// FindClientIDByPhone returns the ID of the client with the given phone number, or 0 if there is none.
+// Any of the client's numbers matches, however it is formatted.
+func (s *SQLite) FindClientIDByPhone(ctx context.Context, raw string) (int64, error) {
 	var clientID int64
-	err := database.DB.QueryRow("SELECT id FROM clients WHERE phone = ?", client.Phone).Scan(&clientID)
-	
+	err := s.db.QueryRowContext(ctx, "SELECT client_id FROM client_phones WHERE phone = ?", phoneKey(raw)).Scan(&clientID)
 	if err == sql.ErrNoRows {
-		// Client does not exist, create new. Photo is mandatory.
-		if client.PhotoData == "" {
-			return 0, errors.New("жаңы клиент үчүн сүрөт милдеттүү")
+		return 0, nil
+	}
+	return clientID, err
+}
+
+// FindOrCreateClient finds a client by phone number or creates a new one.
+// It requires a valid phone number for new clients.
+func (s *SQLite) FindOrCreateClient(ctx context.Context, client models.Client) (int64, error) {
+	// Check if client exists
+	clientID, err := s.FindClientIDByPhone(ctx, client.Phone)
+	if err != nil {
+		return 0, err
+	}
+
+	if clientID == 0 {
+		// Client does not exist, create new
+		normalized, err := phone.Normalize(client.Phone)
+		if err != nil {
+			return 0, err
 		}
 
-		stmt, err := database.DB.Prepare("INSERT INTO clients(fullname, phone, address, photo_data) VALUES(?, ?, ?, ?)")
+		tx, err := s.db.BeginTx(ctx, nil)
 		if err != nil {
 			return 0, err
 		}
-		defer stmt.Close()
+		defer tx.Rollback()
 
-		res, err := stmt.Exec(client.Fullname, client.Phone, client.Address, client.PhotoData)
+		res, err := tx.ExecContext(ctx, "INSERT INTO clients(fullname, phone, address, photo_data) VALUES(?, ?, ?, ?)",
+			client.Fullname, normalized, client.Address, client.PhotoData)
 		if err != nil {
 			return 0, err
 		}
-		return res.LastInsertId()
-	} else if err != nil {
-		return 0, err
+		clientID, err = res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO client_phones(client_id, phone) VALUES(?, ?)", clientID, normalized); err != nil {
+			return 0, err
+		}
+		return clientID, tx.Commit()
 	}
 
 	// Client exists, return ID
 	return clientID, nil
 }
 
+// checkClientExists returns sql.ErrNoRows if there is no such client.
+func (s *SQLite) checkClientExists(ctx context.Context, clientID int64) error {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM clients WHERE id = ?)", clientID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetClientPhones returns all numbers of a client, the main one first.
+// It returns sql.ErrNoRows if there is no such client.
+func (s *SQLite) GetClientPhones(ctx context.Context, clientID int64) ([]string, error) {
+	if err := s.checkClientExists(ctx, clientID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT cp.phone FROM client_phones cp
+		JOIN clients c ON cp.client_id = c.id
+		WHERE cp.client_id = ?
+		ORDER BY cp.phone = c.phone DESC, cp.id`, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	phones := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		phones = append(phones, p)
+	}
+	return phones, rows.Err()
+}
+
+// AddClientPhone adds another number to a client.
+func (s *SQLite) AddClientPhone(ctx context.Context, clientID int64, raw string) error {
+	normalized, err := phone.Normalize(raw)
+	if err != nil {
+		return err
+	}
+
+	owner, err := s.FindClientIDByPhone(ctx, normalized)
+	if err != nil {
+		return err
+	}
+	if owner == clientID {
+		return nil // Already there
+	}
+	if owner != 0 {
+		return ErrPhoneTaken
+	}
+
+	_, err = s.db.ExecContext(ctx, "INSERT INTO client_phones(client_id, phone) VALUES(?, ?)", clientID, normalized)
+	return err
+}
+
+// RemoveClientPhone removes one of a client's extra numbers. The main number stays.
+func (s *SQLite) RemoveClientPhone(ctx context.Context, clientID int64, raw string) error {
+	var mainPhone string
+	if err := s.db.QueryRowContext(ctx, "SELECT phone FROM clients WHERE id = ?", clientID).Scan(&mainPhone); err != nil {
+		return err
+	}
+	key := phoneKey(raw)
+	if key == phoneKey(mainPhone) {
+		return ErrMainPhone
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM client_phones WHERE client_id = ? AND phone = ?", clientID, key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteClient removes a client who has no debts and vouches for none. Their numbers go with them.
+// It returns the client's photo so the caller can remove the file.
+func (s *SQLite) DeleteClient(ctx context.Context, clientID int64) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var photo *string
+	var hasDebts bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT photo_data,
+			EXISTS(SELECT 1 FROM debts WHERE client_id = clients.id) OR
+			EXISTS(SELECT 1 FROM debt_guarantors WHERE client_id = clients.id)
+		FROM clients WHERE id = ?`, clientID).Scan(&photo, &hasDebts)
+	if err != nil {
+		return "", err
+	}
+	if hasDebts {
+		return "", ErrClientHasDebts
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM clients WHERE id = ?", clientID); err != nil {
+		return "", err
+	}
+	unshared, err := unsharedPhoto(ctx, tx, photo)
+	if err != nil {
+		return "", err
+	}
+	return unshared, tx.Commit()
+}
+
+// AnonymizeClient erases a client's name, numbers, address, photo, credit terms and stop-credit
+// reason, the comments on their debts and payments, and the details of their and their debts' audit
+// entries. The debts themselves stay, so totals and history stay right. It returns the old photo so
+// the caller can remove the file.
+func (s *SQLite) AnonymizeClient(ctx context.Context, clientID int64) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var photo *string
+	if err := tx.QueryRowContext(ctx, "SELECT photo_data FROM clients WHERE id = ?", clientID).Scan(&photo); err != nil {
+		return "", err
+	}
+
+	// The phone column is unique and required, so each erased client gets their own placeholder
+	_, err = tx.ExecContext(ctx, `
+		UPDATE clients SET fullname = ?, phone = ?, address = '', photo_data = '',
+			credit_limit = NULL, blocked = 0, blocked_reason = NULL, blocked_at = NULL
+		WHERE id = ?`,
+		models.AnonymizedClientName, fmt.Sprintf("deleted-%d", clientID), clientID)
+	if err != nil {
+		return "", err
+	}
+
+	// Free text the operator typed about the client may name them
+	erase := []string{
+		"DELETE FROM client_phones WHERE client_id = ?",
+		"UPDATE debts SET comment = '', delete_comment = NULL WHERE client_id = ?",
+		"UPDATE debt_payments SET comment = '' WHERE debt_id IN (SELECT id FROM debts WHERE client_id = ?)",
+		"UPDATE audit_log SET details = '' WHERE entity = 'client' AND entity_id = ?",
+		"UPDATE audit_log SET details = '' WHERE entity = 'debt' AND entity_id IN (SELECT id FROM debts WHERE client_id = ?)",
+	}
+	for _, query := range erase {
+		if _, err := tx.ExecContext(ctx, query, clientID); err != nil {
+			return "", err
+		}
+	}
+
+	unshared, err := unsharedPhoto(ctx, tx, photo)
+	if err != nil {
+		return "", err
+	}
+	return unshared, tx.Commit()
+}
+
+// unsharedPhoto returns the photo of a client being removed if no other client uses it, and ""
+// otherwise: a debt can be added with any saved photo, so two clients may share the file.
+func unsharedPhoto(ctx context.Context, tx *sql.Tx, photo *string) (string, error) {
+	if photo == nil || *photo == "" {
+		return "", nil
+	}
+	var shared bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM clients WHERE photo_data = ?)", *photo).Scan(&shared); err != nil {
+		return "", err
+	}
+	if shared {
+		return "", nil
+	}
+	return *photo, nil
+}
+
 // SearchClients searches for clients, checks active debts, and calculates reputation.
-func SearchClients(query string) ([]models.ClientSearchInfo, error) {
+func (s *SQLite) SearchClients(ctx context.Context, query string) ([]models.ClientSearchInfo, error) {
+	fromClause := " FROM clients c"
+	var whereClause, orderBy string
+	var joinArgs, whereArgs []interface{}
+
+	if phoneClause, phoneArgs, ok := phoneSearchClause(query, "c.id"); ok {
+		whereClause, whereArgs = phoneClause, phoneArgs
+	} else if match := ftsMatchQuery(query); s.fts && match != "" {
+		fromClause += " LEFT JOIN (SELECT rowid, rank FROM clients_fts WHERE clients_fts MATCH ?) fts ON fts.rowid = c.id"
+		joinArgs = append(joinArgs, match)
+		whereClause = "fts.rowid IS NOT NULL"
+		orderBy = " ORDER BY COALESCE(fts.rank, 0) ASC"
+	} else {
+		whereClause, whereArgs = nameSearchClause(query, "c.fullname")
+	}
+
 	sqlQuery := `
 		SELECT
 			c.id,
@@ -50,97 +314,322 @@ func SearchClients(query string) ([]models.ClientSearchInfo, error) {
 			c.photo_data,
 			-- Check if there is any active debt
 			EXISTS(SELECT 1 FROM debts d WHERE d.client_id = c.id AND d.status = 'active') as has_active_debt,
-			-- Calculate reputation based on worst rating
-			(
-				SELECT 
-					CASE 
-						WHEN COUNT(CASE WHEN rating = 'untrusted' THEN 1 END) > 0 THEN 'untrusted'
-						WHEN COUNT(CASE WHEN rating = 'bad' THEN 1 END) > 0 THEN 'bad'
-						WHEN COUNT(CASE WHEN rating = 'good' THEN 1 END) > 0 THEN 'good'
-						ELSE 'none'
-					END
-				FROM debts d 
-				WHERE d.client_id = c.id AND d.status = 'paid'
-			) as reputation
-		FROM clients c
-		WHERE c.fullname LIKE ? OR c.phone LIKE ?
-		GROUP BY c.id
+			c.blocked,
+			COALESCE(c.blocked_reason, ''),
+			c.blocked_at,
+			-- Debts of others this client vouched for` + guaranteeColumnsSQL +
+		fromClause + `
+		WHERE ` + whereClause + `
+		GROUP BY c.id` + orderBy + `
 		LIMIT 5;
 	`
-	
-	rows, err := database.DB.Query(sqlQuery, "%"+query+"%", "%"+query+"%")
+
+	termDays, err := s.loadTermDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append([]interface{}{termDays}, joinArgs...)
+	args = append(args, whereArgs...)
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var clients []models.ClientSearchInfo
+	var ids []int64
 	for rows.Next() {
 		var c models.ClientSearchInfo
-		// Handle NULL reputation (if no paid debts)
-		var reputation sql.NullString
-		
-		if err := rows.Scan(&c.ID, &c.Fullname, &c.Phone, &c.Address, &c.PhotoData, &c.HasActiveDebt, &reputation); err != nil {
+		if err := rows.Scan(&c.ID, &c.Fullname, &c.Phone, &c.Address, &c.PhotoData, &c.HasActiveDebt, &c.Blocked, &c.BlockedReason, &c.BlockedAt, &c.GuaranteeExposure, &c.GuaranteeOverdue); err != nil {
 			return nil, err
 		}
-		if reputation.Valid {
-			c.Reputation = reputation.String
-		} else {
-			c.Reputation = "none"
-		}
 		clients = append(clients, c)
+		ids = append(ids, c.ID)
+	}
+
+	// Reputation is a weighted score over the clients' whole history
+	reputations, err := s.LoadReputations(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clients {
+		reputation := reputations[clients[i].ID]
+		clients[i].Reputation = reputation.Label
+		clients[i].ReputationScore = reputation.Score
+
+		if clients[i].Phones, err = s.GetClientPhones(ctx, clients[i].ID); err != nil {
+			return nil, err
+		}
 	}
 	return clients, nil
 }
 
-// GetClients retrieves a paginated list of clients with filters, returning data and total count.
-func GetClients(search, date string, page, limit int) ([]models.Client, int, error) {
-	offset := (page - 1) * limit
-	
+// Per-client figures of the client list. Dates go through datetime() so they compare and scan as plain text.
+const (
+	clientBalanceSQL      = "(SELECT COALESCE(SUM(d.amount), 0) FROM debts d WHERE d.client_id = clients.id AND d.status = 'active')"
+	clientActiveDebtsSQL  = "(SELECT COUNT(*) FROM debts d WHERE d.client_id = clients.id AND d.status = 'active')"
+	clientLastPaymentSQL  = "(SELECT MAX(datetime(p.created_at)) FROM debt_payments p JOIN debts d ON p.debt_id = d.id WHERE d.client_id = clients.id)"
+	clientOldestActiveSQL = "(SELECT MIN(datetime(d.created_at)) FROM debts d WHERE d.client_id = clients.id AND d.status = 'active')"
+	clientLastActivitySQL = "MAX(datetime(clients.created_at)," +
+		" COALESCE((SELECT MAX(datetime(d.created_at)) FROM debts d WHERE d.client_id = clients.id), '')," +
+		" COALESCE(" + clientLastPaymentSQL + ", ''))"
+)
+
+// ClientFilter narrows down the client list. Zero values mean no filter; CreatedTo is exclusive.
+type ClientFilter struct {
+	Search        string
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	HasActiveDebt *bool
+	MinBalance    *float64 // Bounds on the total of active debts
+	MaxBalance    *float64
+	Reputation    string // 'untrusted', 'bad', 'good', or 'none'
+}
+
+// GetClients retrieves a page of clients with filters, each with their balance, activity and reputation.
+// sortBy is "balance_desc", "balance_asc", "activity_desc", "activity_asc", or empty for the newest clients first.
+func (s *SQLite) GetClients(ctx context.Context, filter ClientFilter, sortBy string, page Page) ([]models.ClientListItem, PageResult, error) {
+	var result PageResult
+	search := filter.Search
+
+	fromClause := " FROM clients"
 	whereClause := " WHERE 1=1"
 	args := []interface{}{}
+	order := listOrder{key: "datetime(created_at)", keyDesc: true, id: "clients.id", idDesc: true}
+
+	switch sortBy {
+	case "balance_desc":
+		order = listOrder{key: clientBalanceSQL, keyDesc: true, id: "clients.id", idDesc: true}
+	case "balance_asc":
+		order = listOrder{key: clientBalanceSQL, id: "clients.id"}
+	case "activity_desc":
+		order = listOrder{key: clientLastActivitySQL, keyDesc: true, id: "clients.id", idDesc: true}
+	case "activity_asc":
+		order = listOrder{key: clientLastActivitySQL, id: "clients.id"}
+	}
 
 	if search != "" {
-		whereClause += " AND (fullname LIKE ? OR phone LIKE ? OR address LIKE ?)"
-		searchTerm := "%" + search + "%"
-		args = append(args, searchTerm, searchTerm, searchTerm)
+		if phoneClause, phoneArgs, ok := phoneSearchClause(search, "clients.id"); ok {
+			whereClause += " AND " + phoneClause
+			args = append(args, phoneArgs...)
+		} else if match := ftsMatchQuery(search); s.fts && match != "" {
+			// Best matches first
+			fromClause += " LEFT JOIN (SELECT rowid, rank FROM clients_fts WHERE clients_fts MATCH ?) fts ON fts.rowid = clients.id"
+			args = append(args, match)
+			whereClause += " AND fts.rowid IS NOT NULL"
+			if sortBy == "" || sortBy == "relevance" {
+				order = listOrder{key: "COALESCE(fts.rank, 0)", id: "clients.id", idDesc: true}
+			}
+		} else {
+			nameClause, nameArgs := nameSearchClause(search, "fullname")
+			whereClause += " AND (" + nameClause + " OR fold(address) LIKE ?)"
+			args = append(args, nameArgs...)
+			args = append(args, "%"+textnorm.Fold(search)+"%")
+		}
 	}
 
-	if date != "" {
-		whereClause += " AND date(created_at) = ?"
-		args = append(args, date)
+	rangeClause, rangeArgs := timeRangeClause("created_at", filter.CreatedFrom, filter.CreatedTo)
+	whereClause += rangeClause
+	args = append(args, rangeArgs...)
+
+	if filter.HasActiveDebt != nil {
+		condition := " AND EXISTS(SELECT 1 FROM debts d WHERE d.client_id = clients.id AND d.status = 'active')"
+		if !*filter.HasActiveDebt {
+			condition = " AND NOT EXISTS(SELECT 1 FROM debts d WHERE d.client_id = clients.id AND d.status = 'active')"
+		}
+		whereClause += condition
+	}
+
+	if filter.MinBalance != nil {
+		whereClause += " AND " + clientBalanceSQL + " >= ?"
+		args = append(args, *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		whereClause += " AND " + clientBalanceSQL + " <= ?"
+		args = append(args, *filter.MaxBalance)
+	}
+
+	var afterClause string
+	var afterArgs []interface{}
+	if page.Keyset {
+		var err error
+		if afterClause, afterArgs, err = order.after(page.Cursor); err != nil {
+			return nil, result, err
+		}
+	}
+
+	columns := "SELECT id, fullname, phone, address, photo_data, credit_limit, blocked, COALESCE(blocked_reason, ''), blocked_at, created_at, " +
+		clientBalanceSQL + ", " + clientActiveDebtsSQL + ", " + clientLastPaymentSQL + ", " + clientOldestActiveSQL + ", " + clientLastActivitySQL + ", " +
+		order.key + " AS sort_key"
+
+	// Reputation is computed in Go, so with that filter the whole list is loaded and paged here
+	if filter.Reputation != "" {
+		clients, keys, err := s.scanClients(ctx, columns+fromClause+whereClause+" ORDER BY "+order.orderBy(), args)
+		if err != nil {
+			return nil, result, err
+		}
+
+		ids := make([]int64, len(clients))
+		for i, c := range clients {
+			ids[i] = c.ID
+		}
+		reputations, err := s.LoadReputations(ctx, ids)
+		if err != nil {
+			return nil, result, err
+		}
+
+		// Past the cursor: the rows the cursor condition still lets through
+		var afterIDs map[int64]bool
+		if afterClause != "" {
+			if afterIDs, err = s.queryIDSet(ctx, "SELECT clients.id"+fromClause+whereClause+afterClause, append(args, afterArgs...)); err != nil {
+				return nil, result, err
+			}
+		}
+
+		matched := []models.ClientListItem{}
+		var matchedKeys []interface{}
+		total := 0
+		for i, c := range clients {
+			if reputations[c.ID].Label != filter.Reputation {
+				continue
+			}
+			total++
+			if afterIDs == nil || afterIDs[c.ID] {
+				c.Reputation = reputations[c.ID].Label
+				c.ReputationScore = reputations[c.ID].Score
+				matched = append(matched, c)
+				matchedKeys = append(matchedKeys, keys[i])
+			}
+		}
+		result.Total = &total
+
+		if page.Keyset {
+			if !page.WithTotal {
+				result.Total = nil
+			}
+			matched, result = keysetPage(matched, matchedKeys, page, result)
+			return matched, result, nil
+		}
+
+		start, end := pageBounds(page.Number, page.Limit, total)
+		return matched[start:end], result, nil
 	}
 
 	// 1. Get Total Count
-	countQuery := "SELECT COUNT(*) FROM clients" + whereClause
-	var totalCount int
-	err := database.DB.QueryRow(countQuery, args...).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, err
+	if !page.Keyset || page.WithTotal {
+		countQuery := "SELECT COUNT(*)" + fromClause + whereClause
+		var totalCount int
+		err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+		if err != nil {
+			return nil, result, err
+		}
+		result.Total = &totalCount
 	}
 
 	// 2. Get Data
-	query := "SELECT id, fullname, phone, address, photo_data, created_at FROM clients" + whereClause + " ORDER BY created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	var clients []models.ClientListItem
+	if page.Keyset {
+		query := columns + fromClause + whereClause + afterClause + " ORDER BY " + order.orderBy() + " LIMIT ?"
+		fetched, keys, err := s.scanClients(ctx, query, append(append(args, afterArgs...), page.Limit+1))
+		if err != nil {
+			return nil, result, err
+		}
+		clients, result = keysetPage(fetched, keys, page, result)
+	} else {
+		query := columns + fromClause + whereClause + " ORDER BY " + order.orderBy() + " LIMIT ? OFFSET ?"
+		var err error
+		if clients, _, err = s.scanClients(ctx, query, append(args, page.Limit, (page.Number-1)*page.Limit)); err != nil {
+			return nil, result, err
+		}
+	}
+
+	// 3. Reputation of the clients on the page
+	ids := make([]int64, len(clients))
+	for i, c := range clients {
+		ids[i] = c.ID
+	}
+	reputations, err := s.LoadReputations(ctx, ids)
+	if err != nil {
+		return nil, result, err
+	}
+	for i := range clients {
+		clients[i].Reputation = reputations[clients[i].ID].Label
+		clients[i].ReputationScore = reputations[clients[i].ID].Score
+	}
+	return clients, result, nil
+}
 
-	rows, err := database.DB.Query(query, args...)
+// scanClients runs a client list query and reads its rows along with each row's sort key.
+func (s *SQLite) scanClients(ctx context.Context, query string, args []interface{}) ([]models.ClientListItem, []interface{}, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var clients []models.Client
+	clients := []models.ClientListItem{}
+	var keys []interface{}
 	for rows.Next() {
-		var c models.Client
-		if err := rows.Scan(&c.ID, &c.Fullname, &c.Phone, &c.Address, &c.PhotoData, &c.CreatedAt); err != nil {
-			return nil, 0, err
+		var c models.ClientListItem
+		var lastPayment, oldestActive *string
+		var lastActivity string
+		var key interface{}
+		if err := rows.Scan(&c.ID, &c.Fullname, &c.Phone, &c.Address, &c.PhotoData, &c.CreditLimit, &c.Blocked, &c.BlockedReason, &c.BlockedAt, &c.CreatedAt,
+			&c.Outstanding, &c.ActiveDebts, &lastPayment, &oldestActive, &lastActivity, &key); err != nil {
+			return nil, nil, err
+		}
+		if c.LastPaymentAt, err = parseSQLTime(lastPayment); err != nil {
+			return nil, nil, err
+		}
+		if c.OldestActiveDebtAt, err = parseSQLTime(oldestActive); err != nil {
+			return nil, nil, err
+		}
+		if c.LastActivityAt, err = time.Parse(sqlTimeLayout, lastActivity); err != nil {
+			return nil, nil, err
 		}
 		clients = append(clients, c)
+		keys = append(keys, key)
 	}
+	return clients, keys, rows.Err()
+}
 
-	if clients == nil {
-		clients = []models.Client{}
+// parseSQLTime reads a datetime() result, which is UTC; nil stays nil.
+func parseSQLTime(s *string) (*time.Time, error) {
+	if s == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(sqlTimeLayout, *s)
+	if err != nil {
+		return nil, err
 	}
+	return &t, nil
+}
 
-	return clients, totalCount, nil
+// keysetPage cuts clients fetched one past the page limit down to the page and sets the next cursor.
+func keysetPage(clients []models.ClientListItem, keys []interface{}, page Page, result PageResult) ([]models.ClientListItem, PageResult) {
+	if len(clients) > page.Limit {
+		clients = clients[:page.Limit]
+		result.NextCursor = encodeCursor(keys[page.Limit-1], clients[page.Limit-1].ID)
+	}
+	return clients, result
+}
+
+// queryIDSet runs a query selecting ids and returns them as a set.
+func (s *SQLite) queryIDSet(ctx context.Context, query string, args []interface{}) (map[int64]bool, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
 }
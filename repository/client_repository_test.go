@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"testing"
+	"time"
+)
+
+func TestAnonymizeClient(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan, err := s.FindOrCreateClient(ctx, models.Client{Fullname: "Асан", Phone: "0555123456", Address: "Ош", PhotoData: "/uploads/asan.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddClientPhone(ctx, asan, "0700999888"); err != nil {
+		t.Fatal(err)
+	}
+	limit := 500.0
+	if err := s.SetCreditLimit(ctx, asan, &limit); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetClientBlocked(ctx, asan, true, "Асан from the market never pays"); err != nil {
+		t.Fatal(err)
+	}
+	active := addTestDebt(t, s, asan, 1000, "Асан's bread", time.Time{})
+	payTestDebt(t, s, active, 300, "")
+	deleted := addTestDebt(t, s, asan, 70, "typo", time.Time{})
+	if err := s.DeleteDebt(ctx, deleted, "Асан asked to cancel"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddAuditEntry(ctx, models.AuditEntityDebt, deleted, "deleted", "Асан asked to cancel"); err != nil {
+		t.Fatal(err)
+	}
+
+	photo, err := s.AnonymizeClient(ctx, asan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if photo != "/uploads/asan.jpg" {
+		t.Errorf("photo to remove = %q; want Асан's", photo)
+	}
+
+	client, err := s.GetClient(ctx, asan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Fullname != models.AnonymizedClientName || client.Address != "" || client.PhotoData != "" ||
+		client.CreditLimit != nil || client.Blocked || client.BlockedReason != "" {
+		t.Errorf("anonymized client = %+v", client)
+	}
+	if phones, err := s.GetClientPhones(ctx, asan); err != nil || len(phones) != 0 {
+		t.Errorf("phones = %v, %v; want none", phones, err)
+	}
+	if id, _ := s.FindClientIDByPhone(ctx, "0555123456"); id != 0 {
+		t.Errorf("the old number still finds client %d", id)
+	}
+
+	// The debts stay for the totals, without what was written about them
+	for _, id := range []int64{active, deleted} {
+		debt, err := s.GetDebt(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if debt.Comment != "" || debt.DeleteComment != "" {
+			t.Errorf("debt %d keeps %q, %q", id, debt.Comment, debt.DeleteComment)
+		}
+	}
+	if debt, _ := s.GetDebt(ctx, active); debt.Amount != 700 || debt.Status != models.StatusActive {
+		t.Errorf("active debt = %+v; want 700 still owed", debt)
+	}
+	payments, err := s.GetDebtPayments(ctx, active)
+	if err != nil || len(payments) != 1 || payments[0].Comment != "" || payments[0].PaidAmount != 300 {
+		t.Errorf("payments = %+v, %v; want the 300 without its comment", payments, err)
+	}
+	for entity, id := range map[string]int64{models.AuditEntityClient: asan, models.AuditEntityDebt: deleted} {
+		entries, err := s.GetAuditEntries(ctx, entity, id)
+		if err != nil || len(entries) == 0 {
+			t.Fatalf("%s %d: audit = %v, %v", entity, id, entries, err)
+		}
+		for _, e := range entries {
+			if e.Details != "" {
+				t.Errorf("%s %d: audit entry %q keeps %q", entity, id, e.Action, e.Details)
+			}
+		}
+	}
+}
+
+func TestAnonymizeClientSharedPhoto(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	for _, phone := range []string{"0555123456", "0555000000"} {
+		if _, err := s.FindOrCreateClient(ctx, models.Client{Fullname: "Асан", Phone: phone, PhotoData: "/uploads/asan.jpg"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if photo, err := s.AnonymizeClient(ctx, 1); err != nil || photo != "" {
+		t.Errorf("AnonymizeClient = %q, %v; want the shared photo kept", photo, err)
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/i18n"
+	"debtNote/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseCreditLimit parses the default credit limit. Like a client's own limit, 0 means no credit;
+// an empty value means no limit and gives nil.
+func ParseCreditLimit(s string) (*float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	limit, err := strconv.ParseFloat(s, 64)
+	if err != nil || limit < 0 {
+		return nil, i18n.New("invalid_credit_limit")
+	}
+	return &limit, nil
+}
+
+// ParseBlockedReputations parses a comma separated list of reputation labels.
+func ParseBlockedReputations(s string) ([]string, error) {
+	var labels []string
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		switch label {
+		case "":
+			continue
+		case string(models.RatingGood), string(models.RatingBad), string(models.RatingUntrusted), "none":
+			labels = append(labels, label)
+		default:
+			return nil, i18n.New("unknown_reputation", label)
+		}
+	}
+	return labels, nil
+}
+
+// CheckCredit decides whether a client may take a new debt of the given amount.
+// clientID 0 stands for a client that does not exist yet: no balance and no history.
+func (s *SQLite) CheckCredit(ctx context.Context, clientID int64, amount float64) (models.CreditCheck, error) {
+	check := models.CreditCheck{Allowed: true, Requested: amount, Reputation: "none"}
+
+	// 1. An explicit block wins over everything else
+	var clientLimit sql.NullFloat64
+	if clientID > 0 {
+		var blocked bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT c.credit_limit, c.blocked, COALESCE(c.blocked_reason, ''),
+				COALESCE((SELECT SUM(d.amount) FROM debts d WHERE d.client_id = c.id AND d.status = ?), 0)
+			FROM clients c WHERE c.id = ?`, models.StatusActive, clientID,
+		).Scan(&clientLimit, &blocked, &check.BlockedReason, &check.Outstanding)
+		if err != nil {
+			return check, err
+		}
+		if blocked {
+			check.Allowed = false
+			check.Reason = models.CreditReasonClientBlocked
+			return check, nil
+		}
+	}
+
+	// 2. The limit: the client's own, or the shop default
+	if clientLimit.Valid {
+		check.Limit = &clientLimit.Float64
+	} else {
+		value, err := s.GetSetting(ctx, models.SettingDefaultCreditLimit)
+		if err != nil {
+			return check, err
+		}
+		if check.Limit, err = ParseCreditLimit(value); err != nil {
+			return check, err
+		}
+	}
+
+	// 3. Reputation
+	if clientID > 0 {
+		reputations, err := s.LoadReputations(ctx, []int64{clientID})
+		if err != nil {
+			return check, err
+		}
+		check.Reputation = reputations[clientID].Label
+	}
+
+	value, err := s.GetSetting(ctx, models.SettingBlockedReputations)
+	if err != nil {
+		return check, err
+	}
+	blocked, err := ParseBlockedReputations(value)
+	if err != nil {
+		return check, err
+	}
+	for _, label := range blocked {
+		if label == check.Reputation {
+			check.Allowed = false
+			check.Reason = models.CreditReasonReputation
+			return check, nil
+		}
+	}
+
+	if check.Limit != nil && check.Outstanding+amount > *check.Limit {
+		check.Allowed = false
+		check.Reason = models.CreditReasonLimitExceeded
+	}
+
+	return check, nil
+}
+
+// SetCreditLimit sets a client's own credit limit; nil falls back to the shop default.
+func (s *SQLite) SetCreditLimit(ctx context.Context, clientID int64, limit *float64) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE clients SET credit_limit = ? WHERE id = ?", limit, clientID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetClientBlocked puts a client on the stop-credit list with a reason, or takes them off it,
+// and records the change with the reason in the audit log in the same transaction.
+// It returns sql.ErrNoRows if there is no such client.
+func (s *SQLite) SetClientBlocked(ctx context.Context, clientID int64, blocked bool, reason string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var res sql.Result
+	action := "unblocked"
+	if blocked {
+		res, err = tx.ExecContext(ctx, "UPDATE clients SET blocked = 1, blocked_reason = ?, blocked_at = ? WHERE id = ?",
+			reason, time.Now(), clientID)
+		action = "blocked"
+	} else {
+		res, err = tx.ExecContext(ctx, "UPDATE clients SET blocked = 0, blocked_reason = NULL, blocked_at = NULL WHERE id = ?", clientID)
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO audit_log(entity, entity_id, action, details) VALUES(?, ?, ?, ?)",
+		models.AuditEntityClient, clientID, action, reason)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+	"testing"
+)
+
+func TestParseCreditLimit(t *testing.T) {
+	limit, err := ParseCreditLimit(" ")
+	if limit != nil || err != nil {
+		t.Errorf("ParseCreditLimit(empty) = %v, %v; want no limit", limit, err)
+	}
+
+	for s, want := range map[string]float64{"0": 0, "1500": 1500, " 99.5 ": 99.5} {
+		limit, err := ParseCreditLimit(s)
+		if err != nil || limit == nil || *limit != want {
+			t.Errorf("ParseCreditLimit(%q) = %v, %v; want %v", s, limit, err, want)
+		}
+	}
+
+	for _, s := range []string{"-1", "abc", "1,5"} {
+		if _, err := ParseCreditLimit(s); !hasMessageID(err, "invalid_credit_limit") {
+			t.Errorf("ParseCreditLimit(%q) error = %v; want invalid_credit_limit", s, err)
+		}
+	}
+}
+
+func TestSetClientBlocked(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан", "0555123456")
+
+	if err := s.SetClientBlocked(ctx, asan, true, "never pays"); err != nil {
+		t.Fatal(err)
+	}
+	client, err := s.GetClient(ctx, asan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.Blocked || client.BlockedReason != "never pays" || client.BlockedAt == nil {
+		t.Errorf("client = %+v; want blocked for never paying", client)
+	}
+	if err := s.SetClientBlocked(ctx, asan, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetClientBlocked(ctx, asan+1, true, "unknown"); err != sql.ErrNoRows {
+		t.Errorf("blocking an unknown client: %v; want sql.ErrNoRows", err)
+	}
+
+	// One entry per change, newest first, and none for the unknown client
+	entries, err := s.GetAuditEntries(ctx, models.AuditEntityClient, asan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Action != "unblocked" || entries[1].Action != "blocked" || entries[1].Details != "never pays" {
+		t.Errorf("audit = %+v; want blocked for never paying, then unblocked", entries)
+	}
+	if others, _ := s.GetAuditEntries(ctx, models.AuditEntityClient, asan+1); len(others) != 0 {
+		t.Errorf("audit of the unknown client = %+v", others)
+	}
+}
@@ -1,11 +1,19 @@
 package repository
 
 import (
-	"debtNote/database"
+	"context"
+	"database/sql"
+	"debtNote/i18n"
 	"debtNote/models"
+	"debtNote/textnorm"
+	"encoding/json"
 	"time"
 )
 
+// ErrDebtChanged is returned when a debt is no longer in the state a change was made for,
+// e.g. it was paid or deleted from another window in the meantime.
+var ErrDebtChanged = i18n.New("debt_changed")
+
 // CombinedDebtInfo is a struct for joining client and debt info
 type CombinedDebtInfo struct {
 	DebtID        int64      `json:"debt_id"`
@@ -22,13 +30,33 @@ type CombinedDebtInfo struct {
 	PaidAt        *time.Time `json:"paid_at"`
 	DeletedAt     *time.Time `json:"deleted_at"`
 	DeleteComment string     `json:"delete_comment"`
+	DueDate       *time.Time `json:"due_date"`
+}
+
+// DebtFilter narrows down the debt list. Zero values mean no filter; date ranges include From and exclude To.
+type DebtFilter struct {
+	Search      string
+	Status      string
+	ClientID    int64
+	Rating      string
+	MinAmount   *float64 // Bounds on the amount still owed
+	MaxAmount   *float64
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	PaidFrom    time.Time
+	PaidTo      time.Time
+	DeletedFrom time.Time
+	DeletedTo   time.Time
 }
 
 // GetDebts retrieves a list of debts based on filters, sorting, and pagination.
-func GetDebts(search, date, status string, clientID int64, sortBy string, page, limit int) ([]CombinedDebtInfo, int, error) {
-	offset := (page - 1) * limit
+func (s *SQLite) GetDebts(ctx context.Context, filter DebtFilter, sortBy string, page Page) ([]CombinedDebtInfo, PageResult, error) {
+	var result PageResult
+	status := filter.Status
+	search := filter.Search
 
 	// Base query conditions
+	fromClause := " FROM debts d JOIN clients c ON d.client_id = c.id"
 	whereClause := " WHERE 1=1"
 	args := []interface{}{}
 
@@ -37,152 +65,242 @@ func GetDebts(search, date, status string, clientID int64, sortBy string, page,
 		args = append(args, status)
 	}
 
-	if clientID > 0 {
+	if filter.ClientID > 0 {
 		whereClause += " AND d.client_id = ?"
-		args = append(args, clientID)
+		args = append(args, filter.ClientID)
 	}
 
+	ranked := false
 	if search != "" {
-		whereClause += " AND (c.fullname LIKE ? OR c.phone LIKE ? OR c.address LIKE ? OR d.comment LIKE ?)"
-		searchTerm := "%" + search + "%"
-		args = append(args, searchTerm, searchTerm, searchTerm, searchTerm)
-	}
-
-	if date != "" {
-		// If status is deleted, filter by deleted_at, otherwise created_at
-		if status == "deleted" {
-			whereClause += " AND date(d.deleted_at) = ?"
+		if phoneClause, phoneArgs, ok := phoneSearchClause(search, "c.id"); ok {
+			whereClause += " AND " + phoneClause
+			args = append(args, phoneArgs...)
+		} else if match := ftsMatchQuery(search); s.fts && match != "" {
+			// Full-text index; the match goes in a join so its rank can order the results
+			fromClause += " LEFT JOIN (SELECT rowid, rank FROM debts_fts WHERE debts_fts MATCH ?) fts ON fts.rowid = d.id"
+			// The join comes before the WHERE, so its argument goes first
+			args = append([]interface{}{match}, args...)
+			whereClause += " AND fts.rowid IS NOT NULL"
+			ranked = true
 		} else {
-			whereClause += " AND date(d.created_at) = ?"
+			whereClause += " AND (fold(c.fullname) LIKE ? OR fold(c.address) LIKE ? OR fold(d.comment) LIKE ?)"
+			foldedTerm := "%" + textnorm.Fold(search) + "%"
+			args = append(args, foldedTerm, foldedTerm, foldedTerm)
 		}
-		args = append(args, date)
+	}
+
+	if filter.Rating != "" {
+		whereClause += " AND d.rating = ?"
+		args = append(args, filter.Rating)
+	}
+	if filter.MinAmount != nil {
+		whereClause += " AND d.amount >= ?"
+		args = append(args, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		whereClause += " AND d.amount <= ?"
+		args = append(args, *filter.MaxAmount)
+	}
+
+	for _, r := range []struct {
+		column   string
+		from, to time.Time
+	}{
+		{"d.created_at", filter.CreatedFrom, filter.CreatedTo},
+		{"d.paid_at", filter.PaidFrom, filter.PaidTo},
+		{"d.deleted_at", filter.DeletedFrom, filter.DeletedTo},
+	} {
+		clause, rangeArgs := timeRangeClause(r.column, r.from, r.to)
+		whereClause += clause
+		args = append(args, rangeArgs...)
 	}
 
 	// 1. Get Total Count
-	countQuery := `
-		SELECT COUNT(*)
-		FROM debts d
-		JOIN clients c ON d.client_id = c.id` + whereClause
+	if !page.Keyset || page.WithTotal {
+		countQuery := "SELECT COUNT(*)" + fromClause + whereClause
 
-	var totalCount int
-	err := database.DB.QueryRow(countQuery, args...).Scan(&totalCount)
-	if err != nil {
-		return nil, 0, err
+		var totalCount int
+		err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&totalCount)
+		if err != nil {
+			return nil, result, err
+		}
+		result.Total = &totalCount
 	}
 
-	// Determine Sorting
-	orderBy := "d.created_at DESC, d.id DESC" // Default: Newest first
+	// Determine Sorting. Dates are compared through datetime() so cursors hold plain text.
+	dateColumn := "datetime(d.created_at)"
 	if status == "deleted" {
-		orderBy = "d.deleted_at DESC, d.id DESC"
+		dateColumn = "datetime(d.deleted_at)"
 	}
+	order := listOrder{key: dateColumn, keyDesc: true, id: "d.id", idDesc: true} // Default: Newest first
 
 	switch sortBy {
 	case "date_old":
-		if status == "deleted" {
-			orderBy = "d.deleted_at ASC, d.id ASC"
-		} else {
-			orderBy = "d.created_at ASC, d.id ASC"
-		}
+		order = listOrder{key: dateColumn, id: "d.id"}
 	case "name":
-		orderBy = "c.fullname ASC"
+		order = listOrder{key: "c.fullname", id: "d.id"}
 	case "amount_desc":
-		orderBy = "d.amount DESC"
+		order = listOrder{key: "d.amount", keyDesc: true, id: "d.id", idDesc: true}
 	case "amount_asc":
-		orderBy = "d.amount ASC"
+		order = listOrder{key: "d.amount", id: "d.id"}
+	}
+
+	// Best matches first when searching, unless another order was asked for
+	if ranked && (sortBy == "" || sortBy == "relevance") {
+		order = listOrder{key: "COALESCE(fts.rank, 0)", id: "d.id", idDesc: true}
 	}
 
 	// 2. Get Data
+	pageClause := " LIMIT ? OFFSET ?"
+	if page.Keyset {
+		afterClause, afterArgs, err := order.after(page.Cursor)
+		if err != nil {
+			return nil, result, err
+		}
+		whereClause += afterClause
+		args = append(args, afterArgs...)
+
+		// One extra row tells whether there is a next page
+		pageClause = " LIMIT ?"
+		args = append(args, page.Limit+1)
+	} else {
+		args = append(args, page.Limit, (page.Number-1)*page.Limit)
+	}
+
 	query := `
 		SELECT
 			d.id, d.client_id, c.fullname, c.phone, c.address, c.photo_data,
-			d.amount, d.comment, d.status, d.rating, d.created_at, d.paid_at, d.deleted_at, d.delete_comment
-		FROM debts d
-		JOIN clients c ON d.client_id = c.id` + whereClause + ` ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
+			d.amount, d.comment, d.status, d.rating, d.created_at, d.paid_at, d.deleted_at, d.delete_comment, d.due_date,
+			` + order.key + ` AS sort_key` +
+		fromClause + whereClause + ` ORDER BY ` + order.orderBy() + pageClause
 
-	args = append(args, limit, offset)
-
-	rows, err := database.DB.Query(query, args...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, result, err
 	}
 	defer rows.Close()
 
 	var debts []CombinedDebtInfo
+	var keys []interface{}
 	for rows.Next() {
 		var d CombinedDebtInfo
+		var key interface{}
 		// Handle NULLs for optional fields
 		var deleteComment *string
 
 		if err := rows.Scan(
 			&d.DebtID, &d.ClientID, &d.Fullname, &d.Phone, &d.Address, &d.PhotoData,
-			&d.Amount, &d.Comment, &d.Status, &d.Rating, &d.CreatedAt, &d.PaidAt, &d.DeletedAt, &deleteComment,
+			&d.Amount, &d.Comment, &d.Status, &d.Rating, &d.CreatedAt, &d.PaidAt, &d.DeletedAt, &deleteComment, &d.DueDate,
+			&key,
 		); err != nil {
-			return nil, 0, err
+			return nil, result, err
 		}
 		if deleteComment != nil {
 			d.DeleteComment = *deleteComment
 		}
 		debts = append(debts, d)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, result, err
+	}
+
+	// The extra row means there is more; the cursor points after the last row returned
+	if page.Keyset && len(debts) > page.Limit {
+		debts = debts[:page.Limit]
+		result.NextCursor = encodeCursor(keys[page.Limit-1], debts[page.Limit-1].DebtID)
 	}
 
 	if debts == nil {
 		debts = []CombinedDebtInfo{}
 	}
 
-	return debts, totalCount, nil
+	return debts, result, nil
 }
 
-// AddDebt adds a new debt record for a specific client.
-func AddDebt(debt models.Debt) (int64, error) {
-	stmt, err := database.DB.Prepare("INSERT INTO debts(client_id, amount, comment) VALUES(?, ?, ?)")
+// AddDebt adds a new debt record for a specific client, linked to its guarantors. override is the
+// refused credit check the owner let the debt through, or nil; it goes to the debt's audit log.
+// Either all of it is saved or nothing is.
+func (s *SQLite) AddDebt(ctx context.Context, debt models.Debt, guarantorIDs []int64, override *models.CreditCheck) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	res, err := stmt.Exec(debt.ClientID, debt.Amount, debt.Comment)
+	// The due date is a calendar date, stored without time or zone
+	var dueDate interface{}
+	if debt.DueDate != nil {
+		dueDate = debt.DueDate.Format("2006-01-02")
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO debts(client_id, amount, original_amount, comment, due_date) VALUES(?, ?, ?, ?, ?)",
+		debt.ClientID, debt.Amount, debt.Amount, debt.Comment, dueDate)
 	if err != nil {
 		return 0, err
 	}
-	return res.LastInsertId()
-}
-
-// MakePayment processes a partial or full payment.
-func MakePayment(debtID int64, paidAmount float64, comment string, rating models.DebtRating) error {
-	tx, err := database.DB.Begin()
+	debtID, err := res.LastInsertId()
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer tx.Rollback()
 
-	// 1. Get current debt amount
-	var currentAmount float64
-	err = tx.QueryRow("SELECT amount FROM debts WHERE id = ?", debtID).Scan(&currentAmount)
-	if err != nil {
-		return err
+	if err := addDebtGuarantors(ctx, tx, debtID, guarantorIDs); err != nil {
+		return 0, err
 	}
 
-	remainingAmount := currentAmount - paidAmount
-	if remainingAmount < 0 {
-		remainingAmount = 0
+	if override != nil {
+		details, _ := json.Marshal(override)
+		_, err = tx.ExecContext(ctx, "INSERT INTO audit_log(entity, entity_id, action, details) VALUES(?, ?, ?, ?)",
+			models.AuditEntityDebt, debtID, "credit_override", string(details))
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	// 2. Record the payment
-	_, err = tx.Exec("INSERT INTO debt_payments(debt_id, paid_amount, remaining_amount, comment) VALUES(?, ?, ?, ?)",
-		debtID, paidAmount, remainingAmount, comment)
+	return debtID, tx.Commit()
+}
+
+// GetDebt retrieves a single debt. It returns sql.ErrNoRows if there is none.
+func (s *SQLite) GetDebt(ctx context.Context, debtID int64) (models.Debt, error) {
+	var d models.Debt
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, client_id, amount, COALESCE(comment, ''), status, COALESCE(rating, ''), created_at, paid_at, deleted_at, COALESCE(delete_comment, ''), due_date
+		FROM debts WHERE id = ?`, debtID,
+	).Scan(&d.ID, &d.ClientID, &d.Amount, &d.Comment, &d.Status, &d.Rating, &d.CreatedAt, &d.PaidAt, &d.DeletedAt, &d.DeleteComment, &d.DueDate)
+	return d, err
+}
+
+// MakePayment records a payment on an active debt and closes the debt with rating once nothing remains.
+// owed is the amount the payment was worked out against; if the debt no longer owes it, or is no longer
+// active, nothing is saved and ErrDebtChanged is returned.
+func (s *SQLite) MakePayment(ctx context.Context, payment models.DebtPayment, owed float64, rating models.DebtRating) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	// 3. Update debt amount and status
-	if remainingAmount <= 0 {
+	// 1. Update debt amount and status
+	var res sql.Result
+	if payment.RemainingAmount <= 0 {
 		// Full payment - Close the debt
-		_, err = tx.Exec("UPDATE debts SET amount = 0, status = ?, rating = ?, paid_at = ? WHERE id = ?",
-			models.StatusPaid, rating, time.Now(), debtID)
+		res, err = tx.ExecContext(ctx, "UPDATE debts SET amount = 0, status = ?, rating = ?, paid_at = ? WHERE id = ? AND status = ? AND amount = ?",
+			models.StatusPaid, rating, time.Now(), payment.DebtID, models.StatusActive, owed)
 	} else {
 		// Partial payment - Update amount only
-		_, err = tx.Exec("UPDATE debts SET amount = ? WHERE id = ?", remainingAmount, debtID)
+		res, err = tx.ExecContext(ctx, "UPDATE debts SET amount = ? WHERE id = ? AND status = ? AND amount = ?",
+			payment.RemainingAmount, payment.DebtID, models.StatusActive, owed)
+	}
+	if err != nil {
+		return err
 	}
+	if err := expectOneRow(res); err != nil {
+		return err
+	}
+
+	// 2. Record the payment
+	_, err = tx.ExecContext(ctx, "INSERT INTO debt_payments(debt_id, paid_amount, remaining_amount, comment) VALUES(?, ?, ?, ?)",
+		payment.DebtID, payment.PaidAmount, payment.RemainingAmount, payment.Comment)
 	if err != nil {
 		return err
 	}
@@ -191,9 +309,9 @@ func MakePayment(debtID int64, paidAmount float64, comment string, rating models
 }
 
 // GetDebtPayments retrieves payment history for a specific debt.
-func GetDebtPayments(debtID int64) ([]models.DebtPayment, error) {
+func (s *SQLite) GetDebtPayments(ctx context.Context, debtID int64) ([]models.DebtPayment, error) {
 	query := "SELECT id, debt_id, paid_amount, remaining_amount, comment, created_at FROM debt_payments WHERE debt_id = ? ORDER BY created_at DESC"
-	rows, err := database.DB.Query(query, debtID)
+	rows, err := s.db.QueryContext(ctx, query, debtID)
 	if err != nil {
 		return nil, err
 	}
@@ -211,25 +329,49 @@ func GetDebtPayments(debtID int64) ([]models.DebtPayment, error) {
 }
 
 // PayDebt marks a debt as paid and gives it a rating (Legacy function, kept for compatibility but MakePayment is preferred).
-func PayDebt(debtID int64, rating models.DebtRating) error {
-	stmt, err := database.DB.Prepare("UPDATE debts SET status = ?, rating = ?, paid_at = ? WHERE id = ?")
+func (s *SQLite) PayDebt(ctx context.Context, debtID int64, rating models.DebtRating) error {
+	stmt, err := s.db.PrepareContext(ctx, "UPDATE debts SET status = ?, rating = ?, paid_at = ? WHERE id = ?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(models.StatusPaid, rating, time.Now(), debtID)
+	_, err = stmt.ExecContext(ctx, models.StatusPaid, rating, time.Now(), debtID)
 	return err
 }
 
-// DeleteDebt marks a debt as deleted (soft delete) with a comment and timestamp.
-func DeleteDebt(debtID int64, comment string) error {
-	stmt, err := database.DB.Prepare("UPDATE debts SET status = ?, deleted_at = ?, delete_comment = ? WHERE id = ?")
+// DeleteDebt marks an active debt as deleted (soft delete) with a comment and timestamp.
+// It returns ErrDebtChanged if the debt is not active.
+func (s *SQLite) DeleteDebt(ctx context.Context, debtID int64, comment string) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE debts SET status = ?, deleted_at = ?, delete_comment = ? WHERE id = ? AND status = ?",
+		models.StatusDeleted, time.Now(), comment, debtID, models.StatusActive)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	return expectOneRow(res)
+}
 
-	_, err = stmt.Exec(models.StatusDeleted, time.Now(), comment, debtID)
-	return err
+// RestoreDebt brings a deleted debt back: to paid if it had been paid off, otherwise to active.
+// It returns ErrDebtChanged if the debt is not deleted.
+func (s *SQLite) RestoreDebt(ctx context.Context, debtID int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE debts SET status = CASE WHEN paid_at IS NULL THEN ? ELSE ? END, deleted_at = NULL, delete_comment = NULL
+		WHERE id = ? AND status = ?`,
+		models.StatusActive, models.StatusPaid, debtID, models.StatusDeleted)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// expectOneRow returns ErrDebtChanged unless res updated exactly one row.
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrDebtChanged
+	}
+	return nil
 }
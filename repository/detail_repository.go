@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"time"
+)
+
+// GetDebtDetail collects a debt with its client, payments, repayment schedule and audit log.
+// It returns sql.ErrNoRows if there is no such debt.
+func (s *SQLite) GetDebtDetail(ctx context.Context, debtID int64) (models.DebtDetail, error) {
+	var detail models.DebtDetail
+
+	var err error
+	if detail.Debt, err = s.GetDebt(ctx, debtID); err != nil {
+		return detail, err
+	}
+	if detail.Client, err = s.GetClient(ctx, detail.ClientID); err != nil {
+		return detail, err
+	}
+
+	if detail.Payments, err = s.GetDebtPayments(ctx, debtID); err != nil {
+		return detail, err
+	}
+	if detail.Payments == nil {
+		detail.Payments = []models.DebtPayment{}
+	}
+	for _, p := range detail.Payments {
+		detail.PaidAmount += p.PaidAmount
+	}
+	err = s.db.QueryRowContext(ctx, "SELECT original_amount FROM debts WHERE id = ?", debtID).Scan(&detail.OriginalAmount)
+	if err != nil {
+		return detail, err
+	}
+
+	termDays, err := s.loadTermDays(ctx)
+	if err != nil {
+		return detail, err
+	}
+	detail.Schedule = debtSchedule(detail.Debt, termDays, time.Now())
+
+	if detail.Audit, err = s.auditSummary(ctx, models.AuditEntityDebt, debtID); err != nil {
+		return detail, err
+	}
+	return detail, nil
+}
+
+// debtSchedule works out when debt has to be repaid and whether it is overdue at now,
+// by calendar days in UTC like overdueSQL.
+func debtSchedule(debt models.Debt, termDays int, now time.Time) models.DebtSchedule {
+	created := utcDay(debt.CreatedAt)
+	schedule := models.DebtSchedule{DueDate: created.AddDate(0, 0, termDays), DefaultTerm: true}
+	if debt.DueDate != nil {
+		schedule.DueDate = utcDay(*debt.DueDate)
+		schedule.DefaultTerm = false
+	}
+	schedule.TermDays = daysBetween(created, schedule.DueDate)
+
+	if debt.Status == models.StatusActive {
+		if days := daysBetween(schedule.DueDate, now.UTC()); days > 0 {
+			schedule.Overdue = true
+			schedule.DaysOverdue = days
+		}
+	}
+	return schedule
+}
+
+// utcDay returns the start of t's day in UTC.
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetClientDetail collects a client's profile, numbers, balances, reputation, credit and audit log.
+// It returns sql.ErrNoRows if there is no such client.
+func (s *SQLite) GetClientDetail(ctx context.Context, clientID int64) (models.ClientDetail, error) {
+	var detail models.ClientDetail
+
+	var err error
+	if detail.Client, err = s.GetClient(ctx, clientID); err != nil {
+		return detail, err
+	}
+	if detail.Phones, err = s.GetClientPhones(ctx, clientID); err != nil {
+		return detail, err
+	}
+
+	termDays, err := s.loadTermDays(ctx)
+	if err != nil {
+		return detail, err
+	}
+	b := &detail.Balance
+	err = s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(CASE WHEN d.status = ? THEN d.amount END), 0),
+			COALESCE(SUM(CASE WHEN `+overdueSQL+` THEN d.amount END), 0),
+			COALESCE(SUM(CASE WHEN d.status != ? THEN d.original_amount END), 0),
+			COUNT(CASE WHEN d.status = ? THEN 1 END),
+			COUNT(CASE WHEN d.status = ? THEN 1 END),
+			COUNT(CASE WHEN d.status = ? THEN 1 END)
+		FROM debts d
+		WHERE d.client_id = ?`,
+		models.StatusActive, termDays, models.StatusDeleted,
+		models.StatusActive, models.StatusPaid, models.StatusDeleted, clientID,
+	).Scan(&b.Outstanding, &b.Overdue, &b.Borrowed, &b.ActiveDebts, &b.PaidDebts, &b.DeletedDebts)
+	if err != nil {
+		return detail, err
+	}
+	b.Paid = b.Borrowed - b.Outstanding
+
+	reputations, err := s.LoadReputations(ctx, []int64{clientID})
+	if err != nil {
+		return detail, err
+	}
+	detail.Reputation = reputations[clientID]
+
+	if detail.Credit, err = s.CheckCredit(ctx, clientID, 0); err != nil {
+		return detail, err
+	}
+	// A check for nothing passes a client at their limit; any new debt would not
+	if c := &detail.Credit; c.Allowed && c.Limit != nil && c.Outstanding >= *c.Limit {
+		c.Allowed = false
+		c.Reason = models.CreditReasonLimitExceeded
+	}
+
+	statement, err := s.GetGuarantorStatement(ctx, clientID)
+	if err != nil {
+		return detail, err
+	}
+	detail.GuaranteeExposure = statement.Exposure
+	detail.GuaranteeOverdue = statement.Overdue
+
+	if detail.Audit, err = s.auditSummary(ctx, models.AuditEntityClient, clientID); err != nil {
+		return detail, err
+	}
+	return detail, nil
+}
+
+// auditSummary counts the audit entries of an entity by action and picks the latest.
+func (s *SQLite) auditSummary(ctx context.Context, entity string, entityID int64) (models.AuditSummary, error) {
+	summary := models.AuditSummary{Actions: map[string]int{}}
+	entries, err := s.GetAuditEntries(ctx, entity, entityID)
+	if err != nil {
+		return summary, err
+	}
+	summary.Entries = len(entries)
+	for _, e := range entries {
+		summary.Actions[e.Action]++
+	}
+	if len(entries) > 0 {
+		// Entries come newest first
+		summary.Last = &entries[0]
+	}
+	return summary, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"testing"
+	"time"
+)
+
+func TestGetDebtDetail(t *testing.T) {
+	s := newTestSQLite(t)
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	debtID := addTestDebt(t, s, asan, 1000, "", time.Time{})
+	payTestDebt(t, s, debtID, 400, "")
+
+	detail, err := s.GetDebtDetail(context.Background(), debtID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.OriginalAmount != 1000 || detail.Amount != 600 || detail.PaidAmount != 400 || len(detail.Payments) != 1 {
+		t.Errorf("detail = %+v; want 1000 lent, 400 paid and 600 owed", detail)
+	}
+}
+
+func TestGetClientDetailCredit(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	addTestDebt(t, s, asan, 500, "", time.Time{})
+
+	limit := func(v float64) *float64 { return &v }
+	tests := []struct {
+		limit   *float64
+		allowed bool
+	}{
+		{nil, true},
+		{limit(1000), true},
+		{limit(500), false}, // At the limit: any new debt would exceed it
+		{limit(300), false},
+		{limit(0), false},
+	}
+	for _, tt := range tests {
+		if err := s.SetCreditLimit(ctx, asan, tt.limit); err != nil {
+			t.Fatal(err)
+		}
+		detail, err := s.GetClientDetail(ctx, asan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := detail.Credit
+		wantReason := ""
+		if !tt.allowed {
+			wantReason = models.CreditReasonLimitExceeded
+		}
+		if c.Allowed != tt.allowed || c.Reason != wantReason || c.Outstanding != 500 {
+			t.Errorf("credit = %+v; want allowed %v", c, tt.allowed)
+		}
+	}
+}
+
+func TestDebtSchedule(t *testing.T) {
+	created := time.Date(2026, 3, 1, 22, 30, 0, 0, time.UTC)
+	due := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		debt models.Debt
+		now  time.Time
+		want models.DebtSchedule
+	}{
+		{
+			name: "default term, not yet due",
+			debt: models.Debt{Status: models.StatusActive, CreatedAt: created},
+			now:  day(3, 31),
+			want: models.DebtSchedule{DueDate: day(3, 31).Truncate(24 * time.Hour), DefaultTerm: true, TermDays: 30},
+		},
+		{
+			name: "default term, overdue",
+			debt: models.Debt{Status: models.StatusActive, CreatedAt: created},
+			now:  day(4, 3),
+			want: models.DebtSchedule{DueDate: day(3, 31).Truncate(24 * time.Hour), DefaultTerm: true, TermDays: 30, Overdue: true, DaysOverdue: 3},
+		},
+		{
+			name: "own due date, overdue",
+			debt: models.Debt{Status: models.StatusActive, CreatedAt: created, DueDate: &due},
+			now:  day(3, 20),
+			want: models.DebtSchedule{DueDate: due, TermDays: 14, Overdue: true, DaysOverdue: 5},
+		},
+		{
+			name: "own due date, due today",
+			debt: models.Debt{Status: models.StatusActive, CreatedAt: created, DueDate: &due},
+			now:  day(3, 15),
+			want: models.DebtSchedule{DueDate: due, TermDays: 14},
+		},
+		{
+			name: "paid debts are never overdue",
+			debt: models.Debt{Status: models.StatusPaid, CreatedAt: created, DueDate: &due},
+			now:  day(6, 1),
+			want: models.DebtSchedule{DueDate: due, TermDays: 14},
+		},
+		{
+			name: "days are counted in UTC",
+			debt: models.Debt{Status: models.StatusActive, CreatedAt: created.In(time.FixedZone("KGT", 6*3600))},
+			now:  day(3, 31),
+			want: models.DebtSchedule{DueDate: day(3, 31).Truncate(24 * time.Hour), DefaultTerm: true, TermDays: 30},
+		},
+	}
+	for _, tt := range tests {
+		got := debtSchedule(tt.debt, 30, tt.now)
+		if !got.DueDate.Equal(tt.want.DueDate) || got.DefaultTerm != tt.want.DefaultTerm || got.TermDays != tt.want.TermDays ||
+			got.Overdue != tt.want.Overdue || got.DaysOverdue != tt.want.DaysOverdue {
+			t.Errorf("%s: debtSchedule = %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+}
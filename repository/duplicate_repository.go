@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"debtNote/phone"
+	"debtNote/textnorm"
+	"math"
+	"sort"
+)
+
+// Thresholds for near-duplicate clients.
+const (
+	duplicateMaxPhoneDistance = 2   // Digits that may differ between two numbers of the same person
+	duplicateMinNameScore     = 0.8 // Name similarity (1 - distance/length) from which names look alike
+	duplicateLimit            = 10
+)
+
+// FindDuplicateCandidates returns existing clients whose name or phone is close to the given ones,
+// most likely first. Names are compared by textnorm.NameKey, phones as normalized digits.
+func (s *SQLite) FindDuplicateCandidates(ctx context.Context, fullname, rawPhone string) ([]models.DuplicateCandidate, error) {
+	nameKey := textnorm.NameKey(fullname)
+	phoneDigits := ""
+	if normalized, err := phone.Normalize(rawPhone); err == nil {
+		phoneDigits = phone.Digits(normalized)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.fullname, c.phone, c.address, c.photo_data, cp.phone
+		FROM clients c
+		LEFT JOIN client_phones cp ON cp.client_id = c.id
+		ORDER BY c.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]*models.DuplicateCandidate)
+	var order []int64
+	nameScores := make(map[int64]float64)
+	phoneScores := make(map[int64]float64)
+
+	for rows.Next() {
+		var c models.DuplicateCandidate
+		var clientPhone *string
+		if err := rows.Scan(&c.ClientID, &c.Fullname, &c.Phone, &c.Address, &c.PhotoData, &clientPhone); err != nil {
+			return nil, err
+		}
+
+		candidate, seen := byID[c.ClientID]
+		if !seen {
+			candidate = &c
+			candidate.Reasons = []models.DuplicateReason{}
+			byID[c.ClientID] = candidate
+			order = append(order, c.ClientID)
+
+			// The name only needs checking once per client
+			if nameKey != "" {
+				otherKey := textnorm.NameKey(c.Fullname)
+				distance := textnorm.Distance(nameKey, otherKey)
+				longest := math.Max(float64(len([]rune(nameKey))), float64(len([]rune(otherKey))))
+				if score := 1 - float64(distance)/longest; score >= duplicateMinNameScore {
+					nameScores[c.ClientID] = score
+					candidate.Reasons = append(candidate.Reasons, models.DuplicateReason{Field: "name", Value: c.Fullname, Distance: distance})
+				}
+			}
+		}
+
+		if phoneDigits != "" && clientPhone != nil {
+			otherDigits := phone.Digits(*clientPhone)
+			distance := textnorm.Distance(phoneDigits, otherDigits)
+			if distance <= duplicateMaxPhoneDistance && len(otherDigits) > 0 {
+				score := 1 - float64(distance)/float64(duplicateMaxPhoneDistance+1)
+				if score > phoneScores[c.ClientID] {
+					phoneScores[c.ClientID] = score
+				}
+				candidate.Reasons = append(candidate.Reasons, models.DuplicateReason{Field: "phone", Value: *clientPhone, Distance: distance})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	candidates := []models.DuplicateCandidate{}
+	for _, id := range order {
+		c := byID[id]
+		if len(c.Reasons) == 0 {
+			continue
+		}
+		// Either a close name or a close number is enough to warn; both together is almost certain
+		c.Score = math.Round((nameScores[id]+phoneScores[id])/2*100) / 100
+		candidates = append(candidates, *c)
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].Score > candidates[b].Score })
+	if len(candidates) > duplicateLimit {
+		candidates = candidates[:duplicateLimit]
+	}
+	return candidates, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"time"
+)
+
+// GetClient retrieves a single client. It returns sql.ErrNoRows if there is none.
+func (s *SQLite) GetClient(ctx context.Context, clientID int64) (models.Client, error) {
+	var c models.Client
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, fullname, phone, COALESCE(address, ''), COALESCE(photo_data, ''), credit_limit, blocked, COALESCE(blocked_reason, ''), blocked_at, created_at FROM clients WHERE id = ?",
+		clientID,
+	).Scan(&c.ID, &c.Fullname, &c.Phone, &c.Address, &c.PhotoData, &c.CreditLimit, &c.Blocked, &c.BlockedReason, &c.BlockedAt, &c.CreatedAt)
+	return c, err
+}
+
+// GetClientExport collects everything stored about a client. It returns sql.ErrNoRows if there is no such client.
+func (s *SQLite) GetClientExport(ctx context.Context, clientID int64) (models.ClientExport, error) {
+	export := models.ClientExport{ExportedAt: time.Now().UTC()}
+
+	var err error
+	if export.Client, err = s.GetClient(ctx, clientID); err != nil {
+		return export, err
+	}
+	if export.Phones, err = s.GetClientPhones(ctx, clientID); err != nil {
+		return export, err
+	}
+
+	// 1. Debts, whatever their status
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, client_id, amount, comment, status, COALESCE(rating, ''), created_at, paid_at, deleted_at, COALESCE(delete_comment, ''), due_date
+		FROM debts WHERE client_id = ? ORDER BY created_at, id`, clientID)
+	if err != nil {
+		return export, err
+	}
+	defer rows.Close()
+
+	export.Debts = []models.Debt{}
+	for rows.Next() {
+		var d models.Debt
+		if err := rows.Scan(&d.ID, &d.ClientID, &d.Amount, &d.Comment, &d.Status, &d.Rating, &d.CreatedAt, &d.PaidAt, &d.DeletedAt, &d.DeleteComment, &d.DueDate); err != nil {
+			return export, err
+		}
+		export.Debts = append(export.Debts, d)
+	}
+	if err := rows.Err(); err != nil {
+		return export, err
+	}
+
+	// 2. Payments on them
+	paymentRows, err := s.db.QueryContext(ctx, `
+		SELECT p.id, p.debt_id, p.paid_amount, p.remaining_amount, p.comment, p.created_at
+		FROM debt_payments p JOIN debts d ON p.debt_id = d.id
+		WHERE d.client_id = ? ORDER BY p.created_at, p.id`, clientID)
+	if err != nil {
+		return export, err
+	}
+	defer paymentRows.Close()
+
+	export.Payments = []models.DebtPayment{}
+	for paymentRows.Next() {
+		var p models.DebtPayment
+		if err := paymentRows.Scan(&p.ID, &p.DebtID, &p.PaidAmount, &p.RemainingAmount, &p.Comment, &p.CreatedAt); err != nil {
+			return export, err
+		}
+		export.Payments = append(export.Payments, p)
+	}
+	if err := paymentRows.Err(); err != nil {
+		return export, err
+	}
+
+	// 3. Audit trail of the client and each debt
+	if export.Audit, err = s.GetAuditEntries(ctx, models.AuditEntityClient, clientID); err != nil {
+		return export, err
+	}
+	for _, d := range export.Debts {
+		entries, err := s.GetAuditEntries(ctx, models.AuditEntityDebt, d.ID)
+		if err != nil {
+			return export, err
+		}
+		export.Audit = append(export.Audit, entries...)
+	}
+
+	return export, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+)
+
+// overdueSQL is true for an active debt "d" past its due date, or, without one, past the default
+// term after it was created. It takes the term in days as its only parameter.
+const overdueSQL = `(d.status = 'active' AND date('now') > COALESCE(date(d.due_date), date(d.created_at, '+' || ? || ' days')))`
+
+// addDebtGuarantors links guarantor clients to a debt.
+func addDebtGuarantors(ctx context.Context, tx *sql.Tx, debtID int64, clientIDs []int64) error {
+	for _, clientID := range clientIDs {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO debt_guarantors(debt_id, client_id) VALUES(?, ?)", debtID, clientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGuarantorStatement lists the debts a client vouched for, with the open exposure and overdue flag.
+// It returns sql.ErrNoRows if there is no such client.
+func (s *SQLite) GetGuarantorStatement(ctx context.Context, clientID int64) (models.GuarantorStatement, error) {
+	statement := models.GuarantorStatement{ClientID: clientID, Debts: []models.Guarantee{}}
+	if err := s.checkClientExists(ctx, clientID); err != nil {
+		return statement, err
+	}
+
+	termDays, err := s.loadTermDays(ctx)
+	if err != nil {
+		return statement, err
+	}
+
+	query := `
+		SELECT d.id, c.id, c.fullname, c.phone, d.amount, d.status, d.created_at, d.due_date, ` + overdueSQL + `
+		FROM debt_guarantors g
+		JOIN debts d ON g.debt_id = d.id
+		JOIN clients c ON d.client_id = c.id
+		WHERE g.client_id = ? AND d.status != ?
+		ORDER BY d.created_at DESC, d.id DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, termDays, clientID, models.StatusDeleted)
+	if err != nil {
+		return statement, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var g models.Guarantee
+		if err := rows.Scan(&g.DebtID, &g.BorrowerID, &g.BorrowerName, &g.BorrowerPhone, &g.Amount, &g.Status, &g.CreatedAt, &g.DueDate, &g.Overdue); err != nil {
+			return statement, err
+		}
+		if g.Status == models.StatusActive {
+			statement.Exposure += g.Amount
+		}
+		if g.Overdue {
+			statement.Overdue = true
+		}
+		statement.Debts = append(statement.Debts, g)
+	}
+	return statement, rows.Err()
+}
+
+// guaranteeColumnsSQL adds the guarantee exposure and overdue flag of client "c" to a select list.
+// It takes the default term in days as its only parameter.
+const guaranteeColumnsSQL = `
+			COALESCE((SELECT SUM(d.amount) FROM debt_guarantors g JOIN debts d ON g.debt_id = d.id
+				WHERE g.client_id = c.id AND d.status = 'active'), 0) as guarantee_exposure,
+			EXISTS(SELECT 1 FROM debt_guarantors g JOIN debts d ON g.debt_id = d.id
+				WHERE g.client_id = c.id AND ` + overdueSQL + `) as guarantee_overdue`
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+	"testing"
+)
+
+func TestGetGuarantorStatement(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	bob := addTestClient(t, s, "Bob", "0555000000")
+
+	debtID, err := s.AddDebt(ctx, models.Debt{ClientID: asan, Amount: 1000}, []int64{bob}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payTestDebt(t, s, debtID, 400, "")
+
+	statement, err := s.GetGuarantorStatement(ctx, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statement.Debts) != 1 || statement.Debts[0].DebtID != debtID || statement.Debts[0].Amount != 600 {
+		t.Errorf("statement = %+v; want Асан's debt with 600 left", statement)
+	}
+
+	// A client who vouched for nobody has an empty statement; an unknown one has none
+	if statement, err = s.GetGuarantorStatement(ctx, asan); err != nil || len(statement.Debts) != 0 {
+		t.Errorf("Асан's statement = %+v, %v; want it empty", statement, err)
+	}
+	if _, err := s.GetGuarantorStatement(ctx, bob+1); err != sql.ErrNoRows {
+		t.Errorf("unknown client's statement: %v; want sql.ErrNoRows", err)
+	}
+	if _, err := s.GetClientPhones(ctx, bob+1); err != sql.ErrNoRows {
+		t.Errorf("unknown client's phones: %v; want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"debtNote/i18n"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not produced by a previous page.
+var ErrInvalidCursor = i18n.New("invalid_cursor")
+
+// Page selects a page of a list: by number with LIMIT/OFFSET, or, when Keyset is set,
+// as the rows after Cursor (empty for the first page). Keyset pages stay put when new rows arrive.
+type Page struct {
+	Number    int
+	Limit     int
+	Keyset    bool
+	Cursor    string
+	WithTotal bool // Count all matching rows; numbered pages are always counted
+}
+
+// PageResult describes the page that was returned.
+type PageResult struct {
+	Total      *int   // Nil when not counted
+	NextCursor string // Empty on the last page or for numbered pages
+}
+
+// pageBounds returns the slice bounds of page number (counting from 1) of limit items out of total.
+// Page numbers and limits from a query string can be large enough to overflow (number-1)*limit,
+// so the page is checked against total before multiplying.
+func pageBounds(number, limit, total int) (int, int) {
+	if number < 1 {
+		number = 1
+	}
+	if limit < 1 || number-1 > total/limit {
+		return total, total
+	}
+	start := (number - 1) * limit // At most total
+	end := total
+	if limit < total-start {
+		end = start + limit
+	}
+	return start, end
+}
+
+// listOrder is a list's sort: a key expression with the row id as tie-breaker,
+// which makes every row's position unique so a cursor can point between two rows.
+type listOrder struct {
+	key     string
+	keyDesc bool
+	id      string
+	idDesc  bool
+}
+
+// cursor is the position after the last row of a page. It is sent to clients as opaque base64.
+type cursor struct {
+	Key interface{} `json:"k"`
+	ID  int64       `json:"id"`
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+func comparison(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+// orderBy is the ORDER BY list for the sort.
+func (o listOrder) orderBy() string {
+	return o.key + " " + direction(o.keyDesc) + ", " + o.id + " " + direction(o.idDesc)
+}
+
+// decodeCursor reads a cursor made by encodeCursor.
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.Key == nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// after returns an " AND ..." condition for the rows that come after the encoded cursor.
+func (o listOrder) after(encoded string) (string, []interface{}, error) {
+	if encoded == "" {
+		return "", nil, nil
+	}
+	c, err := decodeCursor(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+
+	clause := " AND (" + o.key + " " + comparison(o.keyDesc) + " ? OR (" + o.key + " = ? AND " + o.id + " " + comparison(o.idDesc) + " ?))"
+	return clause, []interface{}{c.Key, c.Key, c.ID}, nil
+}
+
+// encodeCursor makes the cursor for the row with the given sort key and id.
+func encodeCursor(key interface{}, id int64) string {
+	if b, ok := key.([]byte); ok {
+		key = string(b)
+	}
+	raw, _ := json.Marshal(cursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		number, limit, total int
+		start, end           int
+	}{
+		{1, 10, 25, 0, 10},
+		{2, 10, 25, 10, 20},
+		{3, 10, 25, 20, 25}, // Last page is short
+		{4, 10, 25, 25, 25}, // Past the end
+		{1, 10, 0, 0, 0},
+		{0, 10, 25, 0, 10},  // Numbers below 1 mean the first page
+		{-5, 10, 25, 0, 10}, //
+		{1, 0, 25, 25, 25},  // No limit, no rows
+		{2, 30, 25, 25, 25},
+		// Large enough for (number-1)*limit to overflow
+		{math.MaxInt, math.MaxInt, 25, 25, 25},
+		{math.MaxInt / 2, 4, 25, 25, 25},
+		{1, math.MaxInt, 25, 0, 25},
+	}
+	for _, tt := range tests {
+		start, end := pageBounds(tt.number, tt.limit, tt.total)
+		if start != tt.start || end != tt.end {
+			t.Errorf("pageBounds(%d, %d, %d) = %d, %d; want %d, %d", tt.number, tt.limit, tt.total, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	order := listOrder{key: "d.amount", keyDesc: true, id: "d.id", idDesc: true}
+	tests := []struct {
+		key     interface{}
+		wantKey interface{} // As it comes back from JSON
+	}{
+		{"Асан", "Асан"},
+		{[]byte("2026-10-19 01:02:03"), "2026-10-19 01:02:03"},
+		{150.5, 150.5},
+		{int64(42), float64(42)},
+	}
+	for _, tt := range tests {
+		clause, args, err := order.after(encodeCursor(tt.key, 7))
+		if err != nil {
+			t.Errorf("after(encodeCursor(%v, 7)) failed: %v", tt.key, err)
+			continue
+		}
+		wantClause := " AND (d.amount < ? OR (d.amount = ? AND d.id < ?))"
+		if clause != wantClause {
+			t.Errorf("after(encodeCursor(%v, 7)) clause = %q; want %q", tt.key, clause, wantClause)
+		}
+		wantArgs := []interface{}{tt.wantKey, tt.wantKey, int64(7)}
+		if !reflect.DeepEqual(args, wantArgs) {
+			t.Errorf("after(encodeCursor(%v, 7)) args = %#v; want %#v", tt.key, args, wantArgs)
+		}
+	}
+}
+
+func TestCursorAscending(t *testing.T) {
+	order := listOrder{key: "c.fullname", id: "c.id"}
+	clause, _, err := order.after(encodeCursor("a", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " AND (c.fullname > ? OR (c.fullname = ? AND c.id > ?))"; clause != want {
+		t.Errorf("clause = %q; want %q", clause, want)
+	}
+	if got := order.orderBy(); got != "c.fullname ASC, c.id ASC" {
+		t.Errorf("orderBy() = %q", got)
+	}
+}
+
+func TestCursorFirstPage(t *testing.T) {
+	clause, args, err := listOrder{key: "k", id: "id"}.after("")
+	if clause != "" || args != nil || err != nil {
+		t.Errorf(`after("") = %q, %v, %v; want nothing`, clause, args, err)
+	}
+}
+
+func TestCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, encoded := range []string{
+		"not base64!",
+		encode("not json"),
+		encode(`{"id":3}`), // No key
+		encode(`{"k":null,"id":3}`),
+		encode(`{"k":"a","id":"x"}`),
+	} {
+		if _, _, err := (listOrder{key: "k", id: "id"}).after(encoded); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("after(%q) error = %v; want ErrInvalidCursor", encoded, err)
+		}
+	}
+}
+
+func TestKeysetPages(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	bob := addTestClient(t, s, "Bob", "0555000000")
+	aigul := addTestClient(t, s, "Айгүл", "0555000001")
+
+	// Equal amounts and equal times, so only the id tells the rows apart
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	var debtIDs []int64
+	for i, amount := range []float64{300, 100, 300, 200, 300, 100, 50} {
+		clientID := []int64{asan, bob, aigul}[i%3]
+		debtIDs = append(debtIDs, addTestDebt(t, s, clientID, amount, "", created.Add(time.Duration(i%2)*time.Hour)))
+	}
+
+	for _, sortBy := range []string{"", "date_old", "name", "amount_desc", "amount_asc"} {
+		numbered, result, err := s.GetDebts(ctx, DebtFilter{}, sortBy, Page{Number: 1, Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Total == nil || *result.Total != len(debtIDs) {
+			t.Fatalf("%q: total = %v; want %d", sortBy, result.Total, len(debtIDs))
+		}
+
+		var got []CombinedDebtInfo
+		page := Page{Keyset: true, Limit: 3, WithTotal: true}
+		for range len(debtIDs) {
+			debts, result, err := s.GetDebts(ctx, DebtFilter{}, sortBy, page)
+			if err != nil {
+				t.Fatalf("%q after %q: %v", sortBy, page.Cursor, err)
+			}
+			if result.Total == nil || *result.Total != len(debtIDs) {
+				t.Errorf("%q: keyset total = %v; want %d", sortBy, result.Total, len(debtIDs))
+			}
+			got = append(got, debts...)
+			if result.NextCursor == "" {
+				break
+			}
+			page.Cursor = result.NextCursor
+		}
+		if !reflect.DeepEqual(got, numbered) {
+			t.Errorf("%q: cursor pages = %v; want %v", sortBy, got, numbered)
+		}
+	}
+
+	// The reputation filter pages in Go rather than in SQL
+	for _, filter := range []ClientFilter{{}, {Reputation: "none"}} {
+		for _, sortBy := range []string{"", "balance_desc", "balance_asc", "activity_desc", "activity_asc"} {
+			testClientKeysetPages(t, s, filter, sortBy)
+		}
+	}
+
+	if _, _, err := s.GetDebts(ctx, DebtFilter{}, "", Page{Keyset: true, Limit: 3, Cursor: "x"}); err != ErrInvalidCursor {
+		t.Errorf("bad cursor: %v; want ErrInvalidCursor", err)
+	}
+}
+
+// testClientKeysetPages checks that cursor pages of one client each add up to the numbered list.
+func testClientKeysetPages(t *testing.T, s *SQLite, filter ClientFilter, sortBy string) {
+	t.Helper()
+	ctx := context.Background()
+	numbered, _, err := s.GetClients(ctx, filter, sortBy, Page{Number: 1, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	page := Page{Keyset: true, Limit: 1}
+	for range 4 {
+		clients, result, err := s.GetClients(ctx, filter, sortBy, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Total != nil {
+			t.Errorf("%q: counted the clients without total=true", sortBy)
+		}
+		for _, c := range clients {
+			got = append(got, c.ID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+	var want []int64
+	for _, c := range numbered {
+		want = append(want, c.ID)
+	}
+	if !reflect.DeepEqual(got, want) || len(got) != 3 {
+		t.Errorf("%+v %q: cursor pages = %v; want %v", filter, sortBy, got, want)
+	}
+}
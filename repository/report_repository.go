@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"debtNote/i18n"
+	"debtNote/models"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseAgingBuckets parses comma separated bucket bounds in days ("30,60,90") into increasing numbers.
+func ParseAgingBuckets(s string) ([]int, error) {
+	var bounds []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return nil, i18n.New("invalid_aging_bucket", part)
+		}
+		if len(bounds) > 0 && n <= bounds[len(bounds)-1] {
+			return nil, i18n.New("aging_buckets_order")
+		}
+		bounds = append(bounds, n)
+	}
+	if len(bounds) == 0 {
+		return nil, i18n.New("aging_buckets_empty")
+	}
+	return bounds, nil
+}
+
+// agingBuckets turns bucket bounds (30, 60, 90) into ranges 0-30, 31-60, 61-90 and 90+.
+func agingBuckets(bounds []int) []models.AgingBucket {
+	buckets := make([]models.AgingBucket, 0, len(bounds)+1)
+	minDays := 0
+	for _, bound := range bounds {
+		maxDays := bound
+		buckets = append(buckets, models.AgingBucket{
+			Label:   fmt.Sprintf("%d-%d", minDays, maxDays),
+			MinDays: minDays,
+			MaxDays: &maxDays,
+		})
+		minDays = bound + 1
+	}
+	buckets = append(buckets, models.AgingBucket{
+		Label:   fmt.Sprintf("%d+", bounds[len(bounds)-1]),
+		MinDays: minDays,
+	})
+	return buckets
+}
+
+// daysBetween counts calendar days from one local date to another.
+func daysBetween(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Round(toDate.Sub(fromDate).Hours() / 24))
+}
+
+// GetAgingReport groups active balances per client by age as of the given time.
+// Age counts from the due date when one is set, otherwise from the day the debt was created.
+// Debts that are not yet due fall into the first bucket.
+func (s *SQLite) GetAgingReport(ctx context.Context, bounds []int, asOf time.Time) (models.AgingReport, error) {
+	report := models.AgingReport{
+		AsOf:    asOf,
+		Buckets: agingBuckets(bounds),
+		Rows:    []models.AgingRow{},
+		Totals:  make([]float64, len(bounds)+1),
+	}
+
+	query := `
+		SELECT d.client_id, c.fullname, c.phone, d.amount, d.created_at, d.due_date
+		FROM debts d
+		JOIN clients c ON d.client_id = c.id
+		WHERE d.status = ?`
+
+	rows, err := s.db.QueryContext(ctx, query, models.StatusActive)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	rowIndex := make(map[int64]int)
+	for rows.Next() {
+		var clientID int64
+		var fullname, phone string
+		var amount float64
+		var createdAt time.Time
+		var dueDate *time.Time
+
+		if err := rows.Scan(&clientID, &fullname, &phone, &amount, &createdAt, &dueDate); err != nil {
+			return report, err
+		}
+
+		// created_at is stored in UTC, the due date is a plain calendar date
+		age := daysBetween(createdAt.In(asOf.Location()), asOf)
+		if dueDate != nil {
+			age = daysBetween(*dueDate, asOf)
+		}
+
+		bucket := len(bounds)
+		for i, bound := range bounds {
+			if age <= bound {
+				bucket = i
+				break
+			}
+		}
+
+		i, ok := rowIndex[clientID]
+		if !ok {
+			i = len(report.Rows)
+			rowIndex[clientID] = i
+			report.Rows = append(report.Rows, models.AgingRow{
+				ClientID: clientID,
+				Fullname: fullname,
+				Phone:    phone,
+				Amounts:  make([]float64, len(bounds)+1),
+			})
+		}
+
+		report.Rows[i].Amounts[bucket] += amount
+		report.Rows[i].Total += amount
+		report.Totals[bucket] += amount
+		report.GrandTotal += amount
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	// Largest balances first: those are chased first
+	sort.SliceStable(report.Rows, func(a, b int) bool {
+		return report.Rows[a].Total > report.Rows[b].Total
+	})
+
+	return report, nil
+}
+
+// Time series intervals.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// intervalStart returns the start of the day, week (Monday) or month containing t, in loc.
+func intervalStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch interval {
+	case IntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return day
+	}
+}
+
+// nextInterval returns the start of the bucket following start.
+func nextInterval(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// GetTimeSeries buckets credit issued (debts.created_at) and collected (debt_payments.created_at)
+// by day, week or month in loc. Zero from starts at the first debt, zero to ends now.
+// Deleted debts and their payments are left out, as in the dashboard stats.
+func (s *SQLite) GetTimeSeries(ctx context.Context, interval string, from, to time.Time, loc *time.Location) (models.TimeSeries, error) {
+	series := models.TimeSeries{
+		Interval: interval,
+		TimeZone: loc.String(),
+		Points:   []models.TimeSeriesPoint{},
+	}
+
+	if from.IsZero() {
+		// Selecting the column itself (not MIN) keeps its DATETIME type for scanning
+		var first time.Time
+		err := s.db.QueryRowContext(ctx, "SELECT created_at FROM debts WHERE status != ? ORDER BY created_at LIMIT 1", models.StatusDeleted).Scan(&first)
+		if err == sql.ErrNoRows {
+			return series, nil // No debts yet
+		} else if err != nil {
+			return series, err
+		}
+		from = first
+	}
+	from = intervalStart(from, interval, loc)
+
+	// Buckets run up to now when there is no upper bound; the queries stay open-ended
+	end := to
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	// 1. Opening balance: everything issued minus everything collected before the first bucket
+	opening, err := s.getFlowStats(ctx, time.Time{}, from)
+	if err != nil {
+		return series, err
+	}
+	series.Opening = opening.Issued - opening.Collected
+
+	// 2. Empty buckets for the whole range, so the chart has no gaps
+	index := make(map[int64]int)
+	for start := from; start.Before(end); start = nextInterval(start, interval) {
+		index[start.Unix()] = len(series.Points)
+		series.Points = append(series.Points, models.TimeSeriesPoint{Start: start})
+	}
+
+	// 3. Spread the events over the buckets
+	createdClause, createdArgs := timeRangeClause("d.created_at", from, to)
+	issuedQuery := "SELECT d.created_at, d.original_amount FROM debts d WHERE d.status != ?" + createdClause
+	err = s.addTimeSeriesEvents(ctx, &series, index, interval, loc, issuedQuery,
+		append([]interface{}{models.StatusDeleted}, createdArgs...),
+		func(p *models.TimeSeriesPoint, amount float64) { p.Issued += amount })
+	if err != nil {
+		return series, err
+	}
+
+	paymentClause, paymentArgs := timeRangeClause("p.created_at", from, to)
+	collectedQuery := "SELECT p.created_at, p.paid_amount FROM debt_payments p JOIN debts d ON p.debt_id = d.id WHERE d.status != ?" + paymentClause
+	err = s.addTimeSeriesEvents(ctx, &series, index, interval, loc, collectedQuery,
+		append([]interface{}{models.StatusDeleted}, paymentArgs...),
+		func(p *models.TimeSeriesPoint, amount float64) { p.Collected += amount })
+	if err != nil {
+		return series, err
+	}
+
+	// 4. Net change and running outstanding balance
+	outstanding := series.Opening
+	for i := range series.Points {
+		p := &series.Points[i]
+		p.Net = p.Issued - p.Collected
+		outstanding += p.Net
+		p.Outstanding = outstanding
+	}
+
+	return series, nil
+}
+
+// addTimeSeriesEvents runs a query returning (time, amount) rows and adds each amount to its bucket.
+func (s *SQLite) addTimeSeriesEvents(ctx context.Context, series *models.TimeSeries, index map[int64]int, interval string, loc *time.Location,
+	query string, args []interface{}, add func(*models.TimeSeriesPoint, float64)) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var at time.Time
+		var amount float64
+		if err := rows.Scan(&at, &amount); err != nil {
+			return err
+		}
+		if i, ok := index[intervalStart(at, interval, loc).Unix()]; ok {
+			add(&series.Points[i], amount)
+		}
+	}
+	return rows.Err()
+}
+
+// Risk score weights. Each part is scaled to 0..1 and the weights add up to 100.
+const (
+	riskWeightBalance    = 40 // Share of the largest outstanding balance in the shop
+	riskWeightActive     = 15 // Number of active debts, capped at riskMaxActiveDebts
+	riskWeightAge        = 25 // Age of the oldest unpaid debt, capped at riskMaxAgeDays
+	riskWeightReputation = 20 // Reputation score, inverted
+
+	riskMaxActiveDebts = 5
+	riskMaxAgeDays     = 90
+)
+
+// riskSort is a sort of the risk report: a key, number or text, with the client id as tie-breaker.
+type riskSort struct {
+	key  func(models.ClientRisk) interface{}
+	desc bool
+}
+
+// riskSorts holds the sorts of the risk report by sort_by. Numeric keys are float64, as they come
+// back from a cursor.
+var riskSorts = map[string]riskSort{
+	"risk":    {func(r models.ClientRisk) interface{} { return r.RiskScore }, true},
+	"balance": {func(r models.ClientRisk) interface{} { return r.Outstanding }, true},
+	"active":  {func(r models.ClientRisk) interface{} { return float64(r.ActiveDebts) }, true},
+	"oldest":  {func(r models.ClientRisk) interface{} { return float64(r.OldestDays) }, true},
+	"name":    {func(r models.ClientRisk) interface{} { return r.Fullname }, false},
+}
+
+// compare orders the risk with key and id against the one with otherKey and otherID, returning false
+// if the keys are not of the same type.
+func (o riskSort) compare(key interface{}, id int64, otherKey interface{}, otherID int64) (int, bool) {
+	var c int
+	switch k := key.(type) {
+	case float64:
+		other, ok := otherKey.(float64)
+		if !ok {
+			return 0, false
+		}
+		c = cmp.Compare(k, other)
+	case string:
+		other, ok := otherKey.(string)
+		if !ok {
+			return 0, false
+		}
+		c = strings.Compare(k, other)
+	}
+	if o.desc {
+		c = -c
+	}
+	if c == 0 {
+		c = cmp.Compare(id, otherID)
+	}
+	return c, true
+}
+
+// GetClientRisks ranks all clients by risk, sorted by sortBy
+// ("risk" (default), "balance", "active", "oldest" or "name") and paged like the other lists.
+// Scores are computed in Go, so the whole list is ranked and the page cut out of it.
+func (s *SQLite) GetClientRisks(ctx context.Context, sortBy string, page Page) ([]models.ClientRisk, PageResult, error) {
+	var result PageResult
+	query := `
+		SELECT
+			c.id, c.fullname, c.phone, c.photo_data,
+			COALESCE(SUM(CASE WHEN d.status = 'active' THEN d.amount END), 0) as outstanding,
+			COUNT(CASE WHEN d.status = 'active' THEN 1 END) as active_debts,
+			COALESCE(MAX(CASE WHEN d.status = 'active' THEN julianday('now') - julianday(d.created_at) END), 0) as oldest_days
+		FROM clients c
+		LEFT JOIN debts d ON d.client_id = c.id
+		GROUP BY c.id`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, result, err
+	}
+	defer rows.Close()
+
+	risks := []models.ClientRisk{}
+	var ids []int64
+	maxOutstanding := 0.0
+	for rows.Next() {
+		var r models.ClientRisk
+		var oldestDays float64
+		if err := rows.Scan(&r.ClientID, &r.Fullname, &r.Phone, &r.PhotoData, &r.Outstanding, &r.ActiveDebts, &oldestDays); err != nil {
+			return nil, result, err
+		}
+		r.OldestDays = int(oldestDays)
+		if r.Outstanding > maxOutstanding {
+			maxOutstanding = r.Outstanding
+		}
+		risks = append(risks, r)
+		ids = append(ids, r.ClientID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, result, err
+	}
+
+	reputations, err := s.LoadReputations(ctx, ids)
+	if err != nil {
+		return nil, result, err
+	}
+
+	for i := range risks {
+		r := &risks[i]
+		balance := 0.0
+		if maxOutstanding > 0 {
+			balance = r.Outstanding / maxOutstanding
+		}
+		active := math.Min(float64(r.ActiveDebts)/riskMaxActiveDebts, 1)
+		age := math.Min(float64(r.OldestDays)/riskMaxAgeDays, 1)
+
+		reputation := reputations[r.ClientID]
+		r.Reputation = reputation.Label
+		r.ReputationScore = reputation.Score
+
+		// A perfect reputation adds nothing, the worst adds the full weight
+		score := riskWeightBalance*balance + riskWeightActive*active + riskWeightAge*age +
+			riskWeightReputation*(100-reputation.Score)/100
+		r.RiskScore = math.Round(score*10) / 10
+	}
+
+	order, ok := riskSorts[sortBy]
+	if !ok {
+		order = riskSorts["risk"]
+	}
+	slices.SortFunc(risks, func(a, b models.ClientRisk) int {
+		c, _ := order.compare(order.key(a), a.ClientID, order.key(b), b.ClientID)
+		return c
+	})
+
+	total := len(risks)
+	result.Total = &total
+	if !page.Keyset {
+		start, end := pageBounds(page.Number, page.Limit, total)
+		return risks[start:end], result, nil
+	}
+
+	if !page.WithTotal {
+		result.Total = nil
+	}
+	start := 0
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, result, err
+		}
+		start = len(risks)
+		for i, r := range risks {
+			after, ok := order.compare(order.key(r), r.ClientID, c.Key, c.ID)
+			if !ok {
+				return nil, result, ErrInvalidCursor
+			}
+			if after > 0 {
+				start = i
+				break
+			}
+		}
+	}
+	risks = risks[start:]
+	if len(risks) > page.Limit {
+		risks = risks[:page.Limit]
+		last := risks[page.Limit-1]
+		result.NextCursor = encodeCursor(order.key(last), last.ClientID)
+	}
+	return risks, result, nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"debtNote/i18n"
+	"debtNote/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// hasMessageID reports whether err is the catalog message id.
+func hasMessageID(err error, id string) bool {
+	var msg *i18n.Message
+	return errors.As(err, &msg) && msg.ID == id
+}
+
+func TestParseAgingBuckets(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []int
+	}{
+		{"30,60,90", []int{30, 60, 90}},
+		{" 7 , 14 ", []int{7, 14}},
+		{"30,,60,", []int{30, 60}},
+		{"365", []int{365}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAgingBuckets(tt.s)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAgingBuckets(%q) = %v, %v; want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseAgingBucketsInvalid(t *testing.T) {
+	tests := []struct {
+		s  string
+		id string // Catalog message of the error
+	}{
+		{"", "aging_buckets_empty"},
+		{" , ", "aging_buckets_empty"},
+		{"30,abc", "invalid_aging_bucket"},
+		{"0,30", "invalid_aging_bucket"},
+		{"-30", "invalid_aging_bucket"},
+		{"60,30", "aging_buckets_order"},
+		{"30,30", "aging_buckets_order"},
+	}
+	for _, tt := range tests {
+		_, err := ParseAgingBuckets(tt.s)
+		if err == nil || !hasMessageID(err, tt.id) {
+			t.Errorf("ParseAgingBuckets(%q) error = %v; want %s", tt.s, err, tt.id)
+		}
+	}
+}
+
+func TestAgingBuckets(t *testing.T) {
+	buckets := agingBuckets([]int{30, 60})
+	want := []struct {
+		label   string
+		minDays int
+		maxDays int // -1 for none
+	}{
+		{"0-30", 0, 30},
+		{"31-60", 31, 60},
+		{"60+", 61, -1},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("agingBuckets gave %d buckets; want %d", len(buckets), len(want))
+	}
+	for i, w := range want {
+		b := buckets[i]
+		maxDays := -1
+		if b.MaxDays != nil {
+			maxDays = *b.MaxDays
+		}
+		if b.Label != w.label || b.MinDays != w.minDays || maxDays != w.maxDays {
+			t.Errorf("bucket %d = %s %d..%d; want %s %d..%d", i, b.Label, b.MinDays, maxDays, w.label, w.minDays, w.maxDays)
+		}
+	}
+}
+
+func TestGetAgingReport(t *testing.T) {
+	s := newTestSQLite(t)
+	asOf := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	bob := addTestClient(t, s, "Bob", "0555000000")
+
+	addTestDebt(t, s, asan, 100, "", asOf.AddDate(0, 0, -10))
+	addTestDebt(t, s, asan, 200, "", asOf.AddDate(0, 0, -60))
+	paid := addTestDebt(t, s, asan, 50, "", asOf.AddDate(0, 0, -90))
+	payTestDebt(t, s, paid, 50, models.RatingGood)
+	// Debts with a due date age from it, not from when they were lent
+	overdue := addTestDebt(t, s, bob, 400, "", asOf.AddDate(0, 0, -1))
+	s.mustExec(t, "UPDATE debts SET due_date = '2026-06-01' WHERE id = ?", overdue)
+	notDue := addTestDebt(t, s, bob, 30, "", asOf.AddDate(0, 0, -200))
+	s.mustExec(t, "UPDATE debts SET due_date = '2026-12-01' WHERE id = ?", notDue)
+
+	report, err := s.GetAgingReport(context.Background(), []int{30, 60}, asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.AgingRow{
+		{ClientID: bob, Fullname: "Bob", Phone: "+996555000000", Amounts: []float64{30, 0, 400}, Total: 430},
+		{ClientID: asan, Fullname: "Асан", Phone: "+996555123456", Amounts: []float64{100, 200, 0}, Total: 300},
+	}
+	if !reflect.DeepEqual(report.Rows, want) {
+		t.Errorf("rows = %+v; want %+v", report.Rows, want)
+	}
+	if !reflect.DeepEqual(report.Totals, []float64{130, 200, 400}) || report.GrandTotal != 730 {
+		t.Errorf("totals %v, grand total %v; want [130 200 400], 730", report.Totals, report.GrandTotal)
+	}
+}
+
+func TestGetTimeSeries(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	kgt := time.FixedZone("KGT", 6*3600)
+	utc := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+	paidAt := func(debtID int64, at time.Time) {
+		s.mustExec(t, "UPDATE debt_payments SET created_at = ? WHERE debt_id = ?", at.Format(sqlTimeLayout), debtID)
+	}
+	asan := addTestClient(t, s, "Асан", "0555123456")
+
+	// Lent before the range, so part of the opening balance; paid back early on the 2nd in Bishkek
+	before := addTestDebt(t, s, asan, 1000, "", utc(1, 10))
+	payTestDebt(t, s, before, 300, "")
+	paidAt(before, utc(1, 19))
+	later := addTestDebt(t, s, asan, 500, "", utc(3, 5))
+	payTestDebt(t, s, later, 500, models.RatingGood)
+	paidAt(later, utc(4, 17))
+	deleted := addTestDebt(t, s, asan, 70, "", utc(3, 6))
+	if err := s.DeleteDebt(ctx, deleted, "typed twice"); err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2026, 10, 2, 0, 0, 0, 0, kgt)
+	series, err := s.GetTimeSeries(ctx, IntervalDay, from, from.AddDate(0, 0, 3), kgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series.Opening != 1000 {
+		t.Errorf("opening = %v; want 1000", series.Opening)
+	}
+	want := []models.TimeSeriesPoint{
+		{Start: from, Collected: 300, Net: -300, Outstanding: 700},
+		{Start: from.AddDate(0, 0, 1), Issued: 500, Net: 500, Outstanding: 1200},
+		{Start: from.AddDate(0, 0, 2), Collected: 500, Net: -500, Outstanding: 700},
+	}
+	if len(series.Points) != len(want) {
+		t.Fatalf("points = %+v; want %+v", series.Points, want)
+	}
+	for i, p := range series.Points {
+		w := want[i]
+		if !p.Start.Equal(w.Start) || p.Issued != w.Issued || p.Collected != w.Collected || p.Net != w.Net || p.Outstanding != w.Outstanding {
+			t.Errorf("point %d = %+v; want %+v", i, p, w)
+		}
+	}
+}
+
+func TestGetClientRisks(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	now := time.Now()
+	small := addTestClient(t, s, "Small", "0555000001")
+	addTestDebt(t, s, small, 100, "", now)
+	large := addTestClient(t, s, "Large", "0555000002")
+	addTestDebt(t, s, large, 5000, "", now.AddDate(0, 0, -80))
+	addTestDebt(t, s, large, 1000, "", now.AddDate(0, 0, -10))
+	medium := addTestClient(t, s, "Medium", "0555000003")
+	addTestDebt(t, s, medium, 1000, "", now.AddDate(0, 0, -40))
+	settled := addTestClient(t, s, "Settled", "0555000004")
+	paid := addTestDebt(t, s, settled, 300, "", now.AddDate(0, 0, -20))
+	payTestDebt(t, s, paid, 300, models.RatingGood)
+
+	ids := func(risks []models.ClientRisk) []int64 {
+		var ids []int64
+		for _, r := range risks {
+			ids = append(ids, r.ClientID)
+		}
+		return ids
+	}
+
+	risks, result, err := s.GetClientRisks(ctx, "", Page{Number: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(risks); !reflect.DeepEqual(got, []int64{large, medium}) || result.Total == nil || *result.Total != 4 {
+		t.Errorf("riskiest = %v of %v; want [%d %d] of 4", got, result.Total, large, medium)
+	}
+	if risks[0].Outstanding != 6000 || risks[0].ActiveDebts != 2 || risks[0].OldestDays != 80 {
+		t.Errorf("largest debtor = %+v", risks[0])
+	}
+
+	// Cursor pages follow each other without gaps or repeats, in every order
+	for sortBy, want := range map[string][]int64{
+		"balance": {large, medium, small, settled},
+		"name":    {large, medium, settled, small},
+	} {
+		var got []int64
+		page := Page{Keyset: true, Limit: 1}
+		for range 5 {
+			risks, result, err := s.GetClientRisks(ctx, sortBy, page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, ids(risks)...)
+			if result.NextCursor == "" {
+				break
+			}
+			page.Cursor = result.NextCursor
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s by cursor = %v; want %v", sortBy, got, want)
+		}
+	}
+
+	// A cursor from the name order has a text key, which means nothing to a numeric sort
+	_, result, err = s.GetClientRisks(ctx, "name", Page{Keyset: true, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cursor := range []string{result.NextCursor, "not a cursor"} {
+		if _, _, err := s.GetClientRisks(ctx, "balance", Page{Keyset: true, Limit: 1, Cursor: cursor}); err != ErrInvalidCursor {
+			t.Errorf("cursor %q: %v; want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/database"
+	"debtNote/models"
+	"time"
+)
+
+// ClientRepository stores clients, their numbers, credit terms and what they owe as borrowers and guarantors.
+type ClientRepository interface {
+	FindClientIDByPhone(ctx context.Context, raw string) (int64, error)
+	FindOrCreateClient(ctx context.Context, client models.Client) (int64, error)
+	GetClient(ctx context.Context, clientID int64) (models.Client, error)
+	GetClients(ctx context.Context, filter ClientFilter, sortBy string, page Page) ([]models.ClientListItem, PageResult, error)
+	SearchClients(ctx context.Context, query string) ([]models.ClientSearchInfo, error)
+	FindDuplicateCandidates(ctx context.Context, fullname, rawPhone string) ([]models.DuplicateCandidate, error)
+	GetClientDetail(ctx context.Context, clientID int64) (models.ClientDetail, error)
+	GetClientExport(ctx context.Context, clientID int64) (models.ClientExport, error)
+
+	GetClientPhones(ctx context.Context, clientID int64) ([]string, error)
+	AddClientPhone(ctx context.Context, clientID int64, raw string) error
+	RemoveClientPhone(ctx context.Context, clientID int64, raw string) error
+
+	DeleteClient(ctx context.Context, clientID int64) (string, error)
+	AnonymizeClient(ctx context.Context, clientID int64) (string, error)
+
+	CheckCredit(ctx context.Context, clientID int64, amount float64) (models.CreditCheck, error)
+	SetCreditLimit(ctx context.Context, clientID int64, limit *float64) error
+	SetClientBlocked(ctx context.Context, clientID int64, blocked bool, reason string) error
+
+	GetGuarantorStatement(ctx context.Context, clientID int64) (models.GuarantorStatement, error)
+}
+
+// DebtRepository stores debts, their payments and guarantors.
+type DebtRepository interface {
+	GetDebts(ctx context.Context, filter DebtFilter, sortBy string, page Page) ([]CombinedDebtInfo, PageResult, error)
+	GetDebt(ctx context.Context, debtID int64) (models.Debt, error)
+	GetDebtDetail(ctx context.Context, debtID int64) (models.DebtDetail, error)
+	AddDebt(ctx context.Context, debt models.Debt, guarantorIDs []int64, override *models.CreditCheck) (int64, error)
+	MakePayment(ctx context.Context, payment models.DebtPayment, owed float64, rating models.DebtRating) error
+	GetDebtPayments(ctx context.Context, debtID int64) ([]models.DebtPayment, error)
+	DeleteDebt(ctx context.Context, debtID int64, comment string) error
+	RestoreDebt(ctx context.Context, debtID int64) error
+
+	FindPurgeCandidates(ctx context.Context, deletedBefore, paidBefore time.Time) ([]models.ArchivedDebt, error)
+	PurgeDebts(ctx context.Context, ids []int64, deletedBefore, paidBefore time.Time, archive string) error
+}
+
+// SettingsRepository stores the shop settings.
+type SettingsRepository interface {
+	GetSetting(ctx context.Context, key string) (string, error)
+	GetSettings(ctx context.Context) (map[string]string, error)
+	SetSettings(ctx context.Context, values map[string]string) error
+}
+
+// AuditRepository stores the audit log.
+type AuditRepository interface {
+	AddAuditEntry(ctx context.Context, entity string, entityID int64, action, details string) error
+	GetAuditEntries(ctx context.Context, entity string, entityID int64) ([]models.AuditEntry, error)
+	EraseAuditDetails(ctx context.Context, entity string, entityIDs []int64) error
+}
+
+// ReportRepository computes the dashboard and reports.
+type ReportRepository interface {
+	GetStats(ctx context.Context, from, to time.Time, loc *time.Location) (models.DashboardStats, error)
+	GetAgingReport(ctx context.Context, bounds []int, asOf time.Time) (models.AgingReport, error)
+	GetTimeSeries(ctx context.Context, interval string, from, to time.Time, loc *time.Location) (models.TimeSeries, error)
+	GetClientRisks(ctx context.Context, sortBy string, page Page) ([]models.ClientRisk, PageResult, error)
+}
+
+// SQLite implements every repository on one shop's SQLite database, opened with database.Open.
+type SQLite struct {
+	db  *sql.DB
+	fts bool // Search with the full-text tables rather than LIKE
+}
+
+// NewSQLite returns the repositories backed by db.
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{db: db, fts: database.FullTextSearch(db)}
+}
+
+var (
+	_ ClientRepository   = (*SQLite)(nil)
+	_ DebtRepository     = (*SQLite)(nil)
+	_ SettingsRepository = (*SQLite)(nil)
+	_ AuditRepository    = (*SQLite)(nil)
+	_ ReportRepository   = (*SQLite)(nil)
+)
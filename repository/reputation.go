@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"debtNote/i18n"
+	"debtNote/models"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReputationWeights tunes the reputation score. It is stored as JSON in the "reputation_weights" setting;
+// fields left out keep their default.
+type ReputationWeights struct {
+	Good           float64 `json:"good"`            // Value of a debt closed with the "good" rating
+	Bad            float64 `json:"bad"`             // Value of a debt closed with the "bad" rating
+	Untrusted      float64 `json:"untrusted"`       // Value of a debt closed with the "untrusted" rating
+	OnTime         float64 `json:"on_time"`         // Added when the debt was repaid by its due date
+	Late           float64 `json:"late"`            // Added when the debt was repaid after its due date
+	HalfLifeDays   float64 `json:"half_life_days"`  // A repayment this old counts half as much as a fresh one
+	AmountScale    float64 `json:"amount_scale"`    // Larger debts weigh more: 1 + log10(1 + amount/scale)
+	OverduePenalty float64 `json:"overdue_penalty"` // Points taken off for every open overdue debt
+	GoodThreshold  float64 `json:"good_threshold"`  // Score from which the label is "good"
+	BadThreshold   float64 `json:"bad_threshold"`   // Score from which the label is "bad"; below is "untrusted"
+}
+
+// DefaultReputationWeights is used until the shop configures its own.
+var DefaultReputationWeights = ReputationWeights{
+	Good:           0.6,
+	Bad:            -0.6,
+	Untrusted:      -1.5,
+	OnTime:         0.4,
+	Late:           -0.4,
+	HalfLifeDays:   365,
+	AmountScale:    1000,
+	OverduePenalty: 15,
+	GoodThreshold:  60,
+	BadThreshold:   35,
+}
+
+// ParseReputationWeights reads weights from JSON, starting from the defaults.
+func ParseReputationWeights(s string) (ReputationWeights, error) {
+	weights := DefaultReputationWeights
+	if strings.TrimSpace(s) == "" {
+		return weights, nil
+	}
+	if err := json.Unmarshal([]byte(s), &weights); err != nil {
+		return weights, i18n.New("invalid_reputation_weights", err)
+	}
+	if weights.HalfLifeDays <= 0 || weights.AmountScale <= 0 {
+		return weights, i18n.New("reputation_weights_not_positive")
+	}
+	if weights.BadThreshold > weights.GoodThreshold {
+		return weights, i18n.New("reputation_thresholds_reversed")
+	}
+	return weights, nil
+}
+
+// ParseTermDays parses the default repayment term in days.
+func ParseTermDays(s string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || days <= 0 {
+		return 0, i18n.New("invalid_term_days")
+	}
+	return days, nil
+}
+
+// loadTermDays returns the configured default repayment term.
+func (s *SQLite) loadTermDays(ctx context.Context) (int, error) {
+	value, err := s.GetSetting(ctx, models.SettingDefaultTermDays)
+	if err != nil {
+		return 0, err
+	}
+	return ParseTermDays(value)
+}
+
+// reputationHistory is the part of a debt that counts towards its client's reputation.
+type reputationHistory struct {
+	status    models.DebtStatus
+	rating    string
+	amount    float64 // Original amount
+	createdAt time.Time
+	paidAt    *time.Time
+	dueDate   *time.Time
+}
+
+// due returns when the debt had to be repaid: its due date, or the default term after it was created.
+func (h reputationHistory) due(termDays int) time.Time {
+	if h.dueDate != nil {
+		return *h.dueDate
+	}
+	return h.createdAt.AddDate(0, 0, termDays)
+}
+
+// LoadReputations computes the reputation of the given clients.
+// Clients without closed or overdue debts get the neutral "none" reputation.
+func (s *SQLite) LoadReputations(ctx context.Context, clientIDs []int64) (map[int64]models.Reputation, error) {
+	reputations := make(map[int64]models.Reputation, len(clientIDs))
+	if len(clientIDs) == 0 {
+		return reputations, nil
+	}
+
+	weightsValue, err := s.GetSetting(ctx, models.SettingReputationWeights)
+	if err != nil {
+		return nil, err
+	}
+	weights, err := ParseReputationWeights(weightsValue)
+	if err != nil {
+		return nil, err
+	}
+	termDays, err := s.loadTermDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[int64][]reputationHistory)
+	for start := 0; start < len(clientIDs); start += reputationBatchSize {
+		batch := clientIDs[start:min(start+reputationBatchSize, len(clientIDs))]
+		if err := s.loadReputationHistory(ctx, batch, history); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for _, id := range clientIDs {
+		reputations[id] = computeReputation(history[id], weights, termDays, now)
+	}
+	return reputations, nil
+}
+
+// reputationBatchSize is how many clients loadReputationHistory is given at once,
+// well under SQLite's limit on the number of query parameters.
+const reputationBatchSize = 500
+
+// loadReputationHistory adds the paid and active debts of clientIDs to history.
+func (s *SQLite) loadReputationHistory(ctx context.Context, clientIDs []int64, history map[int64][]reputationHistory) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(clientIDs)), ",")
+	args := []interface{}{models.StatusPaid, models.StatusActive}
+	for _, id := range clientIDs {
+		args = append(args, id)
+	}
+
+	query := `
+		SELECT d.client_id, d.status, COALESCE(d.rating, ''), d.original_amount, d.created_at, d.paid_at, d.due_date
+		FROM debts d
+		WHERE d.status IN (?, ?) AND d.client_id IN (` + placeholders + `)`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var clientID int64
+		var h reputationHistory
+		if err := rows.Scan(&clientID, &h.status, &h.rating, &h.amount, &h.createdAt, &h.paidAt, &h.dueDate); err != nil {
+			return err
+		}
+		history[clientID] = append(history[clientID], h)
+	}
+	return rows.Err()
+}
+
+// computeReputation scores a client from 0 to 100, starting from a neutral 50.
+// Every closed debt pulls the score up or down by its rating and punctuality, weighted by
+// recency and amount, so one old bad debt among many good ones barely matters.
+// Every open overdue debt then takes a fixed penalty off.
+func computeReputation(history []reputationHistory, w ReputationWeights, termDays int, now time.Time) models.Reputation {
+	var sum, totalWeight float64
+	overdue := 0
+
+	for _, h := range history {
+		if h.status == models.StatusActive {
+			if now.After(h.due(termDays).AddDate(0, 0, 1)) {
+				overdue++
+			}
+			continue
+		}
+
+		var value float64
+		switch models.DebtRating(h.rating) {
+		case models.RatingGood:
+			value = w.Good
+		case models.RatingBad:
+			value = w.Bad
+		case models.RatingUntrusted:
+			value = w.Untrusted
+		}
+
+		paidAt := h.createdAt
+		if h.paidAt != nil {
+			paidAt = *h.paidAt
+		}
+		if paidAt.Before(h.due(termDays).AddDate(0, 0, 1)) {
+			value += w.OnTime
+		} else {
+			value += w.Late
+		}
+
+		ageDays := now.Sub(paidAt).Hours() / 24
+		recency := math.Pow(0.5, math.Max(ageDays, 0)/w.HalfLifeDays)
+		size := 1 + math.Log10(1+math.Max(h.amount, 0)/w.AmountScale)
+
+		sum += value * recency * size
+		totalWeight += recency * size
+	}
+
+	if totalWeight == 0 && overdue == 0 {
+		return models.Reputation{Score: 50, Label: "none"}
+	}
+
+	score := 50.0
+	if totalWeight > 0 {
+		score += 50 * sum / totalWeight
+	}
+	score -= w.OverduePenalty * float64(overdue)
+	score = math.Round(math.Max(0, math.Min(100, score))*10) / 10
+
+	label := string(models.RatingUntrusted)
+	switch {
+	case score >= w.GoodThreshold:
+		label = string(models.RatingGood)
+	case score >= w.BadThreshold:
+		label = string(models.RatingBad)
+	}
+	return models.Reputation{Score: score, Label: label}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"testing"
+	"time"
+)
+
+func TestLoadReputations(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	newcomer := addTestClient(t, s, "Newcomer", "0555000001")
+	reliable := addTestClient(t, s, "Reliable", "0555000002")
+	onTime := addTestDebt(t, s, reliable, 500, "", now.AddDate(0, 0, -5))
+	payTestDebt(t, s, onTime, 500, models.RatingGood)
+	// An old late debt weighs less than a fresh one repaid on time
+	oldLate := addTestDebt(t, s, reliable, 100, "", now.AddDate(-3, 0, 0))
+	payTestDebt(t, s, oldLate, 100, models.RatingBad)
+	s.mustExec(t, "UPDATE debts SET paid_at = datetime('now', '-2 years') WHERE id = ?", oldLate)
+
+	cheat := addTestClient(t, s, "Cheat", "0555000003")
+	late := addTestDebt(t, s, cheat, 1000, "", now.AddDate(0, 0, -90))
+	payTestDebt(t, s, late, 1000, models.RatingUntrusted)
+	// Deleted debts never count, overdue or not
+	deleted := addTestDebt(t, s, cheat, 100, "", now.AddDate(0, 0, -90))
+	if err := s.DeleteDebt(ctx, deleted, "typed twice"); err != nil {
+		t.Fatal(err)
+	}
+
+	overdue := addTestClient(t, s, "Overdue", "0555000004")
+	addTestDebt(t, s, overdue, 300, "", now.AddDate(0, 0, -60)) // Past the default 30 day term
+	addTestDebt(t, s, overdue, 300, "", now.AddDate(0, 0, -1))
+
+	ids := []int64{newcomer, reliable, cheat, overdue}
+	reputations, err := s.LoadReputations(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(id int64, label string, min, max float64) {
+		t.Helper()
+		if r := reputations[id]; r.Label != label || r.Score < min || r.Score > max {
+			t.Errorf("client %d: %+v; want %s in %v..%v", id, r, label, min, max)
+		}
+	}
+	check(newcomer, "none", 50, 50)
+	check(reliable, "good", 80, 85)
+	check(cheat, "untrusted", 0, 15)
+	check(overdue, "bad", 35, 35)
+
+	// The shop's weights replace the defaults
+	if err := s.SetSettings(ctx, map[string]string{models.SettingReputationWeights: `{"overdue_penalty": 30}`}); err != nil {
+		t.Fatal(err)
+	}
+	if reputations, err = s.LoadReputations(ctx, ids); err != nil {
+		t.Fatal(err)
+	}
+	check(overdue, "untrusted", 20, 20)
+	check(reliable, "good", 80, 85)
+}
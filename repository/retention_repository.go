@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"debtNote/i18n"
+	"debtNote/models"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy says how long closed debts are kept. Zero keeps them forever.
+type RetentionPolicy struct {
+	DeletedMonths int // Months after deletion
+	PaidYears     int // Years after payment
+}
+
+// Cutoffs returns the times before which deleted and paid debts are purged; zero when they are kept.
+func (p RetentionPolicy) Cutoffs(now time.Time) (deletedBefore, paidBefore time.Time) {
+	if p.DeletedMonths > 0 {
+		deletedBefore = now.AddDate(0, -p.DeletedMonths, 0)
+	}
+	if p.PaidYears > 0 {
+		paidBefore = now.AddDate(-p.PaidYears, 0, 0)
+	}
+	return deletedBefore, paidBefore
+}
+
+// Enabled reports whether the policy purges anything.
+func (p RetentionPolicy) Enabled() bool {
+	return p.DeletedMonths > 0 || p.PaidYears > 0
+}
+
+// ParseRetentionPeriod parses a retention period in months or years; 0 means keep forever.
+func ParseRetentionPeriod(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0, i18n.New("invalid_retention_period")
+	}
+	return n, nil
+}
+
+// LoadRetentionPolicy returns the retention policy configured in settings.
+func LoadRetentionPolicy(ctx context.Context, settings SettingsRepository) (RetentionPolicy, error) {
+	var policy RetentionPolicy
+
+	value, err := settings.GetSetting(ctx, models.SettingRetentionDeletedMonths)
+	if err != nil {
+		return policy, err
+	}
+	if policy.DeletedMonths, err = ParseRetentionPeriod(value); err != nil {
+		return policy, err
+	}
+
+	value, err = settings.GetSetting(ctx, models.SettingRetentionPaidYears)
+	if err != nil {
+		return policy, err
+	}
+	policy.PaidYears, err = ParseRetentionPeriod(value)
+	return policy, err
+}
+
+// purgeCondition matches debts past their retention. Zero cutoffs match nothing.
+func purgeCondition(deletedBefore, paidBefore time.Time) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if !deletedBefore.IsZero() {
+		conditions = append(conditions, "(status = 'deleted' AND datetime(deleted_at) < ?)")
+		args = append(args, deletedBefore.UTC().Format(sqlTimeLayout))
+	}
+	if !paidBefore.IsZero() {
+		conditions = append(conditions, "(status = 'paid' AND datetime(paid_at) < ?)")
+		args = append(args, paidBefore.UTC().Format(sqlTimeLayout))
+	}
+	if len(conditions) == 0 {
+		return "0", nil
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// FindPurgeCandidates returns the debts past their retention, with their payments and guarantors.
+func (s *SQLite) FindPurgeCandidates(ctx context.Context, deletedBefore, paidBefore time.Time) ([]models.ArchivedDebt, error) {
+	condition, args := purgeCondition(deletedBefore, paidBefore)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, client_id, amount, comment, status, COALESCE(rating, ''), created_at, paid_at, deleted_at, COALESCE(delete_comment, ''), due_date
+		FROM debts WHERE `+condition+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	debts := []models.ArchivedDebt{}
+	for rows.Next() {
+		var d models.Debt
+		if err := rows.Scan(&d.ID, &d.ClientID, &d.Amount, &d.Comment, &d.Status, &d.Rating, &d.CreatedAt, &d.PaidAt, &d.DeletedAt, &d.DeleteComment, &d.DueDate); err != nil {
+			return nil, err
+		}
+		debts = append(debts, models.ArchivedDebt{Debt: d, Payments: []models.DebtPayment{}, GuarantorIDs: []int64{}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range debts {
+		payments, err := s.GetDebtPayments(ctx, debts[i].Debt.ID)
+		if err != nil {
+			return nil, err
+		}
+		if payments != nil {
+			debts[i].Payments = payments
+		}
+
+		guarantorRows, err := s.db.QueryContext(ctx, "SELECT client_id FROM debt_guarantors WHERE debt_id = ? ORDER BY client_id", debts[i].Debt.ID)
+		if err != nil {
+			return nil, err
+		}
+		for guarantorRows.Next() {
+			var id int64
+			if err := guarantorRows.Scan(&id); err != nil {
+				guarantorRows.Close()
+				return nil, err
+			}
+			debts[i].GuarantorIDs = append(debts[i].GuarantorIDs, id)
+		}
+		guarantorRows.Close()
+	}
+	return debts, nil
+}
+
+// PurgeDebts deletes archived debts together with their payments and guarantors, and records the
+// purge with the archive and the debts' IDs in one audit entry. It fails without deleting anything
+// if one of them no longer matches the cutoffs, so nothing is lost that is not in the archive.
+func (s *SQLite) PurgeDebts(ctx context.Context, ids []int64, deletedBefore, paidBefore time.Time, archive string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	condition, conditionArgs := purgeCondition(deletedBefore, paidBefore)
+	for _, id := range ids {
+		res, err := tx.ExecContext(ctx, "DELETE FROM debts WHERE id = ? AND "+condition, append([]interface{}{id}, conditionArgs...)...)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("debt %d changed since it was archived", id)
+		}
+	}
+
+	details, _ := json.Marshal(struct {
+		Archive string  `json:"archive"`
+		DebtIDs []int64 `json:"debt_ids"`
+	}{archive, ids})
+	_, err = tx.ExecContext(ctx, "INSERT INTO audit_log(entity, entity_id, action, details) VALUES(?, 0, ?, ?)",
+		models.AuditEntityRetention, "purged", string(details))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurgeDebts(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	bob := addTestClient(t, s, "Bob", "0555000000")
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	deletedBefore, paidBefore := RetentionPolicy{DeletedMonths: 3, PaidYears: 1}.Cutoffs(now)
+
+	deleted := func(at time.Time) int64 {
+		id := addTestDebt(t, s, asan, 100, "", time.Time{})
+		s.mustExec(t, "UPDATE debts SET status = ?, deleted_at = ? WHERE id = ?", models.StatusDeleted, at.Format(sqlTimeLayout), id)
+		return id
+	}
+	paid := func(at time.Time) int64 {
+		id := addTestDebt(t, s, asan, 100, "", time.Time{})
+		payTestDebt(t, s, id, 100, models.RatingGood)
+		s.mustExec(t, "UPDATE debts SET paid_at = ? WHERE id = ?", at.Format(sqlTimeLayout), id)
+		return id
+	}
+	oldDeleted := deleted(now.AddDate(0, -5, 0))
+	newDeleted := deleted(now.AddDate(0, -1, 0))
+	oldPaid, err := s.AddDebt(ctx, models.Debt{ClientID: asan, Amount: 300}, []int64{bob}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payTestDebt(t, s, oldPaid, 300, models.RatingGood)
+	s.mustExec(t, "UPDATE debts SET paid_at = ? WHERE id = ?", now.AddDate(-2, 0, 0).Format(sqlTimeLayout), oldPaid)
+	newPaid := paid(now.AddDate(0, -6, 0))
+	active := addTestDebt(t, s, asan, 100, "", now.AddDate(-3, 0, 0))
+
+	candidates, err := s.FindPurgeCandidates(ctx, deletedBefore, paidBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, c := range candidates {
+		ids = append(ids, c.Debt.ID)
+	}
+	if !slices.Equal(ids, []int64{oldDeleted, oldPaid}) {
+		t.Fatalf("candidates = %v; want the old deleted and paid debts %d and %d", ids, oldDeleted, oldPaid)
+	}
+	if c := candidates[1]; len(c.Payments) != 1 || c.Payments[0].PaidAmount != 300 || !slices.Equal(c.GuarantorIDs, []int64{bob}) {
+		t.Errorf("paid candidate = %+v; want its payment and Bob as guarantor", c)
+	}
+
+	// A debt that no longer matches the cutoffs fails the whole purge
+	if err := s.PurgeDebts(ctx, []int64{oldDeleted, newDeleted}, deletedBefore, paidBefore, "archive/x.json.gz"); err == nil {
+		t.Error("PurgeDebts with a recent debt succeeded")
+	}
+	if _, err := s.GetDebt(ctx, oldDeleted); err != nil {
+		t.Errorf("failed purge removed debt %d: %v", oldDeleted, err)
+	}
+
+	if err := s.PurgeDebts(ctx, ids, deletedBefore, paidBefore, "archive/debts.json.gz"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if _, err := s.GetDebt(ctx, id); err == nil {
+			t.Errorf("debt %d survived the purge", id)
+		}
+	}
+	for _, id := range []int64{newDeleted, newPaid, active} {
+		if _, err := s.GetDebt(ctx, id); err != nil {
+			t.Errorf("debt %d was purged: %v", id, err)
+		}
+	}
+	var payments, guarantors int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM debt_payments WHERE debt_id = ?", oldPaid).Scan(&payments); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM debt_guarantors WHERE debt_id = ?", oldPaid).Scan(&guarantors); err != nil {
+		t.Fatal(err)
+	}
+	if payments != 0 || guarantors != 0 {
+		t.Errorf("purged debt keeps %d payments and %d guarantors", payments, guarantors)
+	}
+
+	entries, err := s.GetAuditEntries(ctx, models.AuditEntityRetention, 0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("retention audit = %+v, %v; want one entry", entries, err)
+	}
+	var details struct {
+		Archive string  `json:"archive"`
+		DebtIDs []int64 `json:"debt_ids"`
+	}
+	if err := json.Unmarshal([]byte(entries[0].Details), &details); err != nil {
+		t.Fatal(err)
+	}
+	if details.Archive != "archive/debts.json.gz" || !slices.Equal(details.DebtIDs, ids) {
+		t.Errorf("audit details = %+v", details)
+	}
+}
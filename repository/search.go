@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"debtNote/textnorm"
+	"strings"
+	"unicode"
+)
+
+// ftsMatchQuery turns a search box query into an FTS5 expression. Every word must match the
+// start of a word in any column, either as typed or in one of its transliterated spellings,
+// so "Асан нан" finds Асан's debt with "нан" in the comment. It is empty for a query without words.
+func ftsMatchQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		spellings := append([]string{textnorm.Fold(word)}, textnorm.SearchVariants(word)...)
+
+		var alternatives []string
+		seen := make(map[string]bool)
+		for _, spelling := range spellings {
+			spelling = strings.ReplaceAll(spelling, `"`, "")
+			if seen[spelling] || !strings.ContainsFunc(spelling, isWordRune) {
+				continue
+			}
+			seen[spelling] = true
+			alternatives = append(alternatives, `"`+spelling+`"*`)
+		}
+
+		if len(alternatives) > 0 {
+			terms = append(terms, "("+strings.Join(alternatives, " OR ")+")")
+		}
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,49 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFullTextSearch(t *testing.T) {
+	s := newTestSQLite(t)
+	if !s.fts {
+		t.Fatal("the full-text tables are missing")
+	}
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан Асанов", "0555123456")
+	bakyt := addTestClient(t, s, "Бакыт", "0555000000")
+	bread := addTestDebt(t, s, asan, 100, "нан", time.Now().AddDate(0, 0, -1))
+	groceries := addTestDebt(t, s, asan, 300, "сүт, эт, жумуртка, нан", time.Time{})
+	addTestDebt(t, s, bakyt, 200, "нан", time.Time{})
+
+	// Every word has to match the start of a word in some column, in any spelling
+	debts, _, err := s.GetDebts(ctx, DebtFilter{Search: "Asan на"}, "relevance", Page{Number: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The shorter comment matches best, though the groceries are newer
+	if len(debts) != 2 || debts[0].DebtID != bread || debts[1].DebtID != groceries {
+		t.Errorf("debts matching %q = %+v; want the bread, then the groceries", "Asan на", debts)
+	}
+
+	// The index follows changes to the client
+	s.mustExec(t, "UPDATE clients SET fullname = 'Бакыт Токтогулов' WHERE id = ?", bakyt)
+	clients, err := s.SearchClients(ctx, "токтог")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 1 || clients[0].ID != bakyt {
+		t.Errorf("clients matching %q = %+v; want Бакыт", "токтог", clients)
+	}
+	debts, _, err = s.GetDebts(ctx, DebtFilter{Search: "токтогулов нан"}, "", Page{Number: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(debts) != 1 || debts[0].ClientID != bakyt {
+		t.Errorf("debts matching %q = %+v; want Бакыт's bread", "токтогулов нан", debts)
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// searchTestData adds two clients whose numbers share digits with each other's searches.
+func searchTestData(t *testing.T, s *SQLite) (asan, bakyt int64) {
+	t.Helper()
+	asan = addTestClient(t, s, "Асан", "0555123456")
+	if err := s.AddClientPhone(context.Background(), asan, "0700 999 888"); err != nil {
+		t.Fatal(err)
+	}
+	bakyt = addTestClient(t, s, "Бакыт", "0555 500 200")
+	addTestDebt(t, s, asan, 100, "нан 500", time.Time{})
+	addTestDebt(t, s, bakyt, 200, "сүт", time.Time{})
+	return asan, bakyt
+}
+
+func TestSearchClientsByPhone(t *testing.T) {
+	s := newTestSQLite(t)
+	asan, bakyt := searchTestData(t, s)
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"0555 12", []int64{asan}},
+		{"+996 700 999", []int64{asan}}, // Any of the client's numbers
+		{"500-200", []int64{bakyt}},
+		{"Бакыт", []int64{bakyt}},
+		{"Асан 2", nil}, // Digits next to a name are not a number
+		{"55", nil},     // Too few digits to be a number
+	}
+	for _, tt := range tests {
+		clients, err := s.SearchClients(context.Background(), tt.query)
+		if err != nil {
+			t.Fatalf("SearchClients(%q): %v", tt.query, err)
+		}
+		var got []int64
+		for _, c := range clients {
+			got = append(got, c.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SearchClients(%q) = %v; want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchListsByPhone(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan, bakyt := searchTestData(t, s)
+
+	// "500" is in Бакыт's number, but next to a word it is text
+	debts, _, err := s.GetDebts(ctx, DebtFilter{Search: "нан 500"}, "", Page{Number: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(debts) != 1 || debts[0].ClientID != asan {
+		t.Errorf("debts matching %q = %+v; want Асан's bread", "нан 500", debts)
+	}
+	debts, _, err = s.GetDebts(ctx, DebtFilter{Search: "0555 500"}, "", Page{Number: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(debts) != 1 || debts[0].ClientID != bakyt {
+		t.Errorf("debts matching %q = %+v; want Бакыт's", "0555 500", debts)
+	}
+
+	clients, _, err := s.GetClients(ctx, ClientFilter{Search: "0555"}, "", Page{Number: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 2 {
+		t.Errorf("clients matching %q = %+v; want both", "0555", clients)
+	}
+	clients, _, err = s.GetClients(ctx, ClientFilter{Search: "Асан 2"}, "", Page{Number: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 0 {
+		t.Errorf("clients matching %q = %+v; want none", "Асан 2", clients)
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+)
+
+// GetSetting returns the stored value of a setting, or its default if it was never set.
+func (s *SQLite) GetSetting(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return models.DefaultSettings[key], nil
+	} else if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// GetSettings returns all known settings, with defaults filled in for the ones never set.
+func (s *SQLite) GetSettings(ctx context.Context) (map[string]string, error) {
+	settings := make(map[string]string, len(models.DefaultSettings))
+	for key, value := range models.DefaultSettings {
+		settings[key] = value
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT key, value FROM settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if _, known := models.DefaultSettings[key]; known {
+			settings[key] = value
+		}
+	}
+	return settings, rows.Err()
+}
+
+// SetSettings stores the given settings, replacing any previous values. Either all of them are
+// stored or none.
+func (s *SQLite) SetSettings(ctx context.Context, values map[string]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for key, value := range values {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO settings(key, value, updated_at) VALUES(?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+			key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"testing"
+)
+
+func TestSetSettings(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+
+	err := s.SetSettings(ctx, map[string]string{models.SettingDefaultTermDays: "14", models.SettingLanguage: "ru"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSettings(ctx, map[string]string{models.SettingLanguage: "en"}); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		models.SettingDefaultTermDays:    "14",
+		models.SettingLanguage:           "en",
+		models.SettingBlockedReputations: models.DefaultSettings[models.SettingBlockedReputations],
+	}
+	for key, value := range want {
+		if settings[key] != value {
+			t.Errorf("%s = %q; want %q", key, settings[key], value)
+		}
+	}
+}
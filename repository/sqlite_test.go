@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"debtNote/database"
+	"debtNote/models"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// database.Open reports every table it creates
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// openTestDB opens the in-memory database named name with the app's driver, schema and migrations.
+// Connections to the same name share one database until the last of them closes.
+func openTestDB(t *testing.T, name string) *SQLite {
+	t.Helper()
+	db := database.Open("file:" + url.PathEscape(name) + "?mode=memory&cache=shared")
+	t.Cleanup(func() { db.Close() })
+	return NewSQLite(db)
+}
+
+// newTestSQLite returns the repositories on a fresh in-memory database of the test's own.
+func newTestSQLite(t *testing.T) *SQLite {
+	t.Helper()
+	return openTestDB(t, t.Name())
+}
+
+// addTestClient creates a client and returns their id.
+func addTestClient(t *testing.T, s *SQLite, fullname, phone string) int64 {
+	t.Helper()
+	id, err := s.FindOrCreateClient(context.Background(), models.Client{Fullname: fullname, Phone: phone})
+	if err != nil {
+		t.Fatalf("FindOrCreateClient(%q): %v", fullname, err)
+	}
+	return id
+}
+
+// addTestDebt lends amount to the client, created at created unless that is zero, and returns the debt id.
+func addTestDebt(t *testing.T, s *SQLite, clientID int64, amount float64, comment string, created time.Time) int64 {
+	t.Helper()
+	id, err := s.AddDebt(context.Background(), models.Debt{ClientID: clientID, Amount: amount, Comment: comment}, nil, nil)
+	if err != nil {
+		t.Fatalf("AddDebt: %v", err)
+	}
+	if !created.IsZero() {
+		s.mustExec(t, "UPDATE debts SET created_at = ? WHERE id = ?", created.UTC().Format(sqlTimeLayout), id)
+	}
+	return id
+}
+
+// payTestDebt records a payment of paid as the service does, closing the debt with rating once nothing remains.
+func payTestDebt(t *testing.T, s *SQLite, debtID int64, paid float64, rating models.DebtRating) {
+	t.Helper()
+	ctx := context.Background()
+	debt, err := s.GetDebt(ctx, debtID)
+	if err != nil {
+		t.Fatalf("GetDebt(%d): %v", debtID, err)
+	}
+	payment := models.DebtPayment{DebtID: debtID, PaidAmount: paid, RemainingAmount: max(debt.Amount-paid, 0), Comment: "cash"}
+	if err := s.MakePayment(ctx, payment, debt.Amount, rating); err != nil {
+		t.Fatalf("MakePayment(%d): %v", debtID, err)
+	}
+}
+
+// mustExec runs a statement the repositories have no method for, such as backdating a row.
+func (s *SQLite) mustExec(t *testing.T, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := s.db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
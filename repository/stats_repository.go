@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+	"time"
+)
+
+// sqlTimeLayout matches the format SQLite's datetime() returns, so bounds compare as plain text.
+const sqlTimeLayout = "2006-01-02 15:04:05"
+
+// timeRangeClause returns an " AND ..." condition limiting column to [from, to). Zero bounds are open.
+func timeRangeClause(column string, from, to time.Time) (string, []interface{}) {
+	clause := ""
+	args := []interface{}{}
+	if !from.IsZero() {
+		clause += " AND datetime(" + column + ") >= ?"
+		args = append(args, from.UTC().Format(sqlTimeLayout))
+	}
+	if !to.IsZero() {
+		clause += " AND datetime(" + column + ") < ?"
+		args = append(args, to.UTC().Format(sqlTimeLayout))
+	}
+	return clause, args
+}
+
+// GetStats computes the dashboard overview. from and to bound the period figures; zero values mean no bound.
+// Today, this week and this month are taken in loc.
+func (s *SQLite) GetStats(ctx context.Context, from, to time.Time, loc *time.Location) (models.DashboardStats, error) {
+	var stats models.DashboardStats
+
+	// 1. Current snapshot
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0), COUNT(DISTINCT client_id) FROM debts WHERE status = ?",
+		models.StatusActive,
+	).Scan(&stats.TotalOutstanding, &stats.ActiveDebtors)
+	if err != nil {
+		return stats, err
+	}
+
+	// 2. Fixed windows: today, this week (from Monday) and this month
+	now := time.Now()
+	today := intervalStart(now, IntervalDay, loc)
+	weekStart := intervalStart(now, IntervalWeek, loc)
+	monthStart := intervalStart(now, IntervalMonth, loc)
+
+	if stats.Today, err = s.getFlowStats(ctx, today, time.Time{}); err != nil {
+		return stats, err
+	}
+	if stats.Week, err = s.getFlowStats(ctx, weekStart, time.Time{}); err != nil {
+		return stats, err
+	}
+	if stats.Month, err = s.getFlowStats(ctx, monthStart, time.Time{}); err != nil {
+		return stats, err
+	}
+	if stats.Period, err = s.getFlowStats(ctx, from, to); err != nil {
+		return stats, err
+	}
+
+	// 3. Repayment speed and ratings of debts closed in the period
+	paidClause, paidArgs := timeRangeClause("d.paid_at", from, to)
+	args := append([]interface{}{models.StatusPaid}, paidArgs...)
+
+	var avgDays sql.NullFloat64
+	var good, bad, untrusted int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT
+			AVG(julianday(d.paid_at) - julianday(d.created_at)),
+			COUNT(CASE WHEN d.rating = 'good' THEN 1 END),
+			COUNT(CASE WHEN d.rating = 'bad' THEN 1 END),
+			COUNT(CASE WHEN d.rating = 'untrusted' THEN 1 END)
+		FROM debts d
+		WHERE d.status = ? AND d.paid_at IS NOT NULL`+paidClause, args...,
+	).Scan(&avgDays, &good, &bad, &untrusted)
+	if err != nil {
+		return stats, err
+	}
+
+	if avgDays.Valid {
+		stats.AvgDaysToRepay = avgDays.Float64
+	}
+	stats.RatedDebts = good + bad + untrusted
+	if stats.RatedDebts > 0 {
+		total := float64(stats.RatedDebts)
+		stats.RatingShares = models.RatingShares{
+			Good:      float64(good) / total,
+			Bad:       float64(bad) / total,
+			Untrusted: float64(untrusted) / total,
+		}
+	}
+
+	return stats, nil
+}
+
+// getFlowStats sums credit issued and payments collected in [from, to). Deleted debts are left out.
+func (s *SQLite) getFlowStats(ctx context.Context, from, to time.Time) (models.FlowStats, error) {
+	var flow models.FlowStats
+
+	createdClause, createdArgs := timeRangeClause("d.created_at", from, to)
+	issuedArgs := append([]interface{}{models.StatusDeleted}, createdArgs...)
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(d.original_amount), 0) FROM debts d WHERE d.status != ?"+createdClause,
+		issuedArgs...,
+	).Scan(&flow.Issued)
+	if err != nil {
+		return flow, err
+	}
+
+	paymentClause, paymentArgs := timeRangeClause("p.created_at", from, to)
+	collectedArgs := append([]interface{}{models.StatusDeleted}, paymentArgs...)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(p.paid_amount), 0)
+		FROM debt_payments p
+		JOIN debts d ON p.debt_id = d.id
+		WHERE d.status != ?`+paymentClause, collectedArgs...,
+	).Scan(&flow.Collected)
+	if err != nil {
+		return flow, err
+	}
+
+	return flow, nil
+}
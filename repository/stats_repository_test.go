@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"debtNote/models"
+	"testing"
+	"time"
+)
+
+func TestGetStats(t *testing.T) {
+	s := newTestSQLite(t)
+	ctx := context.Background()
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	bob := addTestClient(t, s, "Bob", "0555000000")
+
+	partly := addTestDebt(t, s, asan, 1000, "flour", time.Time{})
+	payTestDebt(t, s, partly, 300, "")
+	// Handing over more than is owed closes the debt; 500 was lent and 600 came back
+	overpaid := addTestDebt(t, s, bob, 500, "sugar", time.Time{})
+	payTestDebt(t, s, overpaid, 600, models.RatingGood)
+	deleted := addTestDebt(t, s, bob, 70, "typo", time.Time{})
+	if err := s.DeleteDebt(ctx, deleted, "typed twice"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := s.GetStats(ctx, time.Time{}, time.Time{}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalOutstanding != 700 || stats.ActiveDebtors != 1 {
+		t.Errorf("outstanding %v from %d debtors; want 700 from 1", stats.TotalOutstanding, stats.ActiveDebtors)
+	}
+	want := models.FlowStats{Issued: 1500, Collected: 900}
+	if stats.Period != want || stats.Today != want {
+		t.Errorf("period %+v, today %+v; want %+v", stats.Period, stats.Today, want)
+	}
+	if stats.RatedDebts != 1 || stats.RatingShares.Good != 1 {
+		t.Errorf("%d rated debts with shares %+v; want the one good", stats.RatedDebts, stats.RatingShares)
+	}
+
+	// Debts lent before the period are left out of it
+	s.mustExec(t, "UPDATE debts SET created_at = datetime('now', '-40 days') WHERE id = ?", partly)
+	stats, err = s.GetStats(ctx, time.Now().AddDate(0, 0, -7), time.Time{}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Period.Issued != 500 || stats.Month.Issued != 500 {
+		t.Errorf("issued %v in the period and %v this month; want 500", stats.Period.Issued, stats.Month.Issued)
+	}
+}
+
+func TestOriginalAmountBackfill(t *testing.T) {
+	s := newTestSQLite(t)
+	asan := addTestClient(t, s, "Асан", "0555123456")
+	unpaid := addTestDebt(t, s, asan, 200, "", time.Time{})
+	partly := addTestDebt(t, s, asan, 1000, "", time.Time{})
+	payTestDebt(t, s, partly, 300, "")
+	payTestDebt(t, s, partly, 200, "")
+	closed := addTestDebt(t, s, asan, 500, "", time.Time{})
+	payTestDebt(t, s, closed, 600, models.RatingGood)
+
+	// Debts from before the column existed; opening the database again fills it in
+	s.mustExec(t, "UPDATE debts SET original_amount = NULL")
+	openTestDB(t, t.Name())
+
+	// A debt closed by one payment can only be known by what was paid
+	want := map[int64]float64{unpaid: 200, partly: 1000, closed: 600}
+	for id, amount := range want {
+		var got float64
+		if err := s.db.QueryRow("SELECT original_amount FROM debts WHERE id = ?", id).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != amount {
+			t.Errorf("debt %d: original amount %v; want %v", id, got, amount)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+	"debtNote/repository"
+	"fmt"
+	"strings"
+)
+
+// Ways of removing a client.
+const (
+	RemoveDelete    = "delete"    // Remove the client entirely; refused while they have debts
+	RemoveAnonymize = "anonymize" // Erase their personal data and photo but keep the debts
+)
+
+// Clients runs the client workflows that go beyond storing what the operator typed.
+type Clients struct {
+	Clients repository.ClientRepository
+	Audit   repository.AuditRepository
+}
+
+// Remove deletes or anonymizes a client, depending on mode, and removes their photo unless another
+// client uses it too. Either way, the comments on the client's debts that retention purged are
+// erased from the archives, along with the details of those debts' audit entries.
+func (s *Clients) Remove(ctx context.Context, clientID int64, mode string) error {
+	var photo string
+	var err error
+	var action string
+	switch mode {
+	case RemoveDelete:
+		photo, err = s.Clients.DeleteClient(ctx, clientID)
+		action = "deleted"
+	case RemoveAnonymize:
+		photo, err = s.Clients.AnonymizeClient(ctx, clientID)
+		action = "anonymized"
+	default:
+		return invalid("mode", "invalid_choice", "mode", mode, RemoveDelete+", "+RemoveAnonymize)
+	}
+
+	if err == sql.ErrNoRows {
+		return ErrClientNotFound
+	} else if err == repository.ErrClientHasDebts {
+		return ErrClientHasDebts
+	} else if err != nil {
+		return fmt.Errorf("failed to remove client: %w", err)
+	}
+
+	// No personal data in the entry: it has to outlive the erasure
+	if err := s.Audit.AddAuditEntry(ctx, models.AuditEntityClient, clientID, action, ""); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+
+	archived, err := scrubArchives(clientID)
+	if err != nil {
+		return fmt.Errorf("failed to scrub archives: %w", err)
+	}
+	if len(archived) > 0 {
+		if err := s.Audit.EraseAuditDetails(ctx, models.AuditEntityDebt, archived); err != nil {
+			return fmt.Errorf("failed to scrub archived debts' audit entries: %w", err)
+		}
+	}
+
+	if err := removePhoto(photo); err != nil {
+		return fmt.Errorf("failed to remove photo: %w", err)
+	}
+	return nil
+}
+
+// SetCreditLimit sets or clears (nil) a client's own credit limit; 0 means no credit.
+// Only the owner may change it.
+func (s *Clients) SetCreditLimit(ctx context.Context, clientID int64, limit *float64, ownerPin string) error {
+	if limit != nil && *limit < 0 {
+		return invalid("credit_limit", "credit_limit_negative")
+	}
+	if err := CheckOwner(ownerPin); err != nil {
+		return err
+	}
+
+	err := s.Clients.SetCreditLimit(ctx, clientID, limit)
+	if err == sql.ErrNoRows {
+		return ErrClientNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to set credit limit: %w", err)
+	}
+
+	details := "default"
+	if limit != nil {
+		details = fmt.Sprint(*limit)
+	}
+	if err := s.Audit.AddAuditEntry(ctx, models.AuditEntityClient, clientID, "credit_limit_changed", details); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	return nil
+}
+
+// SetBlocked puts a client on the stop-credit list, which needs a reason, or takes them off it,
+// which only the owner may do. The change is recorded in the audit log with the reason.
+func (s *Clients) SetBlocked(ctx context.Context, clientID int64, blocked bool, reason, ownerPin string) error {
+	reason = strings.TrimSpace(reason)
+	if blocked && reason == "" {
+		return invalid("reason", "block_reason_required")
+	}
+	if !blocked {
+		if err := CheckOwner(ownerPin); err != nil {
+			return err
+		}
+	}
+
+	err := s.Clients.SetClientBlocked(ctx, clientID, blocked, reason)
+	if err == sql.ErrNoRows {
+		return ErrClientNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to update client: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"debtNote/models"
+	"debtNote/phone"
+	"debtNote/repository"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Debts runs the debt workflows: adding, paying, deleting and restoring debts.
+type Debts struct {
+	Clients repository.ClientRepository
+	Debts   repository.DebtRepository
+	Audit   repository.AuditRepository
+}
+
+// NewDebt is a debt to add. The borrower is found by phone number, or created from the other fields.
+type NewDebt struct {
+	Fullname   string
+	Phone      string
+	Address    string
+	Photo      string // Path of a photo already saved ("/uploads/..."), or a base64 data URL of a new one
+	Amount     float64
+	Comment    string
+	DueDate    *time.Time // Optional
+	Guarantors []int64    // Optional clients vouching for the debt
+	Override   bool       // Owner only: add the debt even if credit checks refuse it
+	OwnerPin   string     // Required with Override
+}
+
+// Add checks the borrower's credit and the guarantors, then saves the debt, creating the borrower
+// if they are new. It returns the new debt's ID.
+func (s *Debts) Add(ctx context.Context, req NewDebt) (int64, error) {
+	if req.Amount <= 0 {
+		return 0, invalid("amount", "amount_not_positive")
+	}
+
+	// Check the client's credit before anything is saved
+	clientID, err := s.Clients.FindClientIDByPhone(ctx, req.Phone)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find client: %w", err)
+	}
+	if clientID == 0 {
+		if _, err := phone.Normalize(req.Phone); err != nil {
+			return 0, invalid("phone", "invalid_phone")
+		}
+		if req.Photo == "" {
+			return 0, invalid("photo_data", "photo_required")
+		}
+	}
+
+	credit, err := s.Clients.CheckCredit(ctx, clientID, req.Amount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check credit: %w", err)
+	}
+	if !credit.Allowed {
+		if !req.Override {
+			return 0, &CreditRefusedError{Credit: credit}
+		}
+		if !isOwner(req.OwnerPin) {
+			return 0, ErrOwnerPinRequired
+		}
+	}
+
+	if err := s.checkGuarantors(ctx, clientID, req.Guarantors); err != nil {
+		return 0, err
+	}
+
+	// A new photo is saved to a file; a path means the client's current photo was sent back
+	photoPath := req.Photo
+	if photoPath != "" && !strings.HasPrefix(photoPath, "/uploads/") {
+		if photoPath, err = savePhoto(req.Photo, req.Fullname); err != nil {
+			return 0, fmt.Errorf("failed to save image: %w", err)
+		}
+	}
+
+	// 1. Find or create the client
+	clientID, err = s.Clients.FindOrCreateClient(ctx, models.Client{
+		Fullname:  req.Fullname,
+		Phone:     req.Phone,
+		Address:   req.Address,
+		PhotoData: photoPath,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to process client: %w", err)
+	}
+
+	// 2. Add the debt for the client with its guarantors, leaving a trace when the owner
+	// let a refused debt through
+	var override *models.CreditCheck
+	if !credit.Allowed {
+		override = &credit
+	}
+	debtID, err := s.Debts.AddDebt(ctx, models.Debt{
+		ClientID: clientID,
+		Amount:   req.Amount,
+		Comment:  req.Comment,
+		DueDate:  req.DueDate,
+	}, req.Guarantors, override)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add debt: %w", err)
+	}
+	return debtID, nil
+}
+
+// checkGuarantors verifies that every guarantor exists and is not the borrower (0 for a new client).
+func (s *Debts) checkGuarantors(ctx context.Context, borrowerID int64, clientIDs []int64) error {
+	for _, clientID := range clientIDs {
+		if clientID == borrowerID {
+			return invalid("guarantor_ids", "guarantor_is_borrower")
+		}
+		if _, err := s.Clients.GetClient(ctx, clientID); err == sql.ErrNoRows {
+			return invalid("guarantor_ids", "guarantor_not_found")
+		} else if err != nil {
+			return fmt.Errorf("failed to find guarantor: %w", err)
+		}
+	}
+	return nil
+}
+
+// Payment is money paid towards a debt.
+type Payment struct {
+	DebtID  int64
+	Amount  float64
+	Comment string
+	Rating  models.DebtRating // Given to the debt if the payment closes it
+}
+
+// Pay records a payment and closes the debt once nothing remains. Paying more than is owed
+// closes the debt too; the payment is recorded as paid. It returns the saved payment.
+func (s *Debts) Pay(ctx context.Context, p Payment) (models.DebtPayment, error) {
+	payment := models.DebtPayment{DebtID: p.DebtID, PaidAmount: p.Amount, Comment: p.Comment}
+
+	if p.Amount <= 0 {
+		return payment, invalid("paid_amount", "amount_not_positive")
+	}
+	if p.Comment == "" {
+		return payment, invalid("comment", "comment_required")
+	}
+	switch p.Rating {
+	case "", models.RatingGood, models.RatingBad, models.RatingUntrusted:
+	default:
+		return payment, invalid("rating", "invalid_rating")
+	}
+
+	debt, err := s.activeDebt(ctx, p.DebtID)
+	if err != nil {
+		return payment, err
+	}
+
+	payment.RemainingAmount = debt.Amount - p.Amount
+	if payment.RemainingAmount < 0 {
+		payment.RemainingAmount = 0
+	}
+	if err := s.Debts.MakePayment(ctx, payment, debt.Amount, p.Rating); err != nil {
+		return payment, fmt.Errorf("failed to make payment: %w", err)
+	}
+	return payment, nil
+}
+
+// Delete moves an active debt to the trash, giving the reason in comment, which goes to the audit log too.
+func (s *Debts) Delete(ctx context.Context, debtID int64, comment string) error {
+	if comment == "" {
+		return invalid("comment", "delete_reason_required")
+	}
+	if _, err := s.activeDebt(ctx, debtID); err != nil {
+		return err
+	}
+	if err := s.Debts.DeleteDebt(ctx, debtID, comment); err != nil {
+		return fmt.Errorf("failed to delete debt: %w", err)
+	}
+	if err := s.Audit.AddAuditEntry(ctx, models.AuditEntityDebt, debtID, "deleted", comment); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	return nil
+}
+
+// Restore takes a debt out of the trash, back to active or paid.
+func (s *Debts) Restore(ctx context.Context, debtID int64) error {
+	debt, err := s.getDebt(ctx, debtID)
+	if err != nil {
+		return err
+	}
+	if debt.Status != models.StatusDeleted {
+		return ErrDebtNotDeleted
+	}
+	if err := s.Debts.RestoreDebt(ctx, debtID); err != nil {
+		return fmt.Errorf("failed to restore debt: %w", err)
+	}
+	if err := s.Audit.AddAuditEntry(ctx, models.AuditEntityDebt, debtID, "restored", ""); err != nil {
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+	return nil
+}
+
+// getDebt loads a debt, returning ErrDebtNotFound if there is none.
+func (s *Debts) getDebt(ctx context.Context, debtID int64) (models.Debt, error) {
+	debt, err := s.Debts.GetDebt(ctx, debtID)
+	if err == sql.ErrNoRows {
+		return debt, ErrDebtNotFound
+	} else if err != nil {
+		return debt, fmt.Errorf("failed to get debt: %w", err)
+	}
+	return debt, nil
+}
+
+// activeDebt loads a debt that can still be paid or deleted.
+func (s *Debts) activeDebt(ctx context.Context, debtID int64) (models.Debt, error) {
+	debt, err := s.getDebt(ctx, debtID)
+	if err != nil {
+		return debt, err
+	}
+	if debt.Status != models.StatusActive {
+		return debt, ErrDebtNotActive
+	}
+	return debt, nil
+}
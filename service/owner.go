@@ -0,0 +1,27 @@
+package service
+
+import (
+	"crypto/subtle"
+	"os"
+)
+
+// ownerPinEnv names the environment variable holding the shop owner's PIN.
+// Without it, owner-only overrides are disabled.
+const ownerPinEnv = "DEBTNOTE_OWNER_PIN"
+
+// CheckOwner returns ErrOwnerPinRequired unless pin is the owner's PIN.
+func CheckOwner(pin string) error {
+	if !isOwner(pin) {
+		return ErrOwnerPinRequired
+	}
+	return nil
+}
+
+// isOwner reports whether pin matches the owner's PIN.
+func isOwner(pin string) bool {
+	ownerPin := os.Getenv(ownerPinEnv)
+	if ownerPin == "" || pin == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(pin), []byte(ownerPin)) == 1
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// savePhoto decodes base64 image and saves it to disk
+func savePhoto(base64Data, fullname string) (string, error) {
+	// Remove the data URL prefix (e.g., "data:image/jpeg;base64,")
+	parts := strings.Split(base64Data, ",")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid base64 data")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	// Create directory for current month: uploads/YYYY-MM
+	currentMonth := time.Now().Format("2006-01")
+	dirPath := filepath.Join("uploads", currentMonth)
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return "", err
+	}
+
+	// Sanitize fullname for filename (Allow letters, numbers, spaces, underscores, hyphens)
+	// We use unicode.IsLetter to support Cyrillic and other scripts
+	safeName := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' || r == '-' {
+			return r
+		}
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return -1 // Drop other characters
+	}, fullname)
+
+	// Fallback if name becomes empty
+	if safeName == "" {
+		safeName = "unknown"
+	}
+
+	// Generate filename: Name_Date_Random.jpg
+	dateStr := time.Now().Format("2006-01-02")
+	randNum := rand.Intn(100000)
+	filename := fmt.Sprintf("%s_%s_%d.jpg", safeName, dateStr, randNum)
+	filePath := filepath.Join(dirPath, filename)
+
+	// Write file
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return "", err
+	}
+
+	// Return the path relative to the server root, using forward slashes for URL compatibility
+	// e.g., /uploads/2023-10/John_Doe_2023-10-27_123.jpg
+	return "/" + filepath.ToSlash(filePath), nil
+}
+
+// PhotoFile maps a photo path saved by savePhoto to its file. It reports false for anything
+// that is not a file under uploads/ (e.g. photos stored inline before files were used).
+func PhotoFile(photoPath string) (string, bool) {
+	if !strings.HasPrefix(photoPath, "/uploads/") {
+		return "", false
+	}
+	filePath := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(photoPath, "/")))
+	if !strings.HasPrefix(filePath, "uploads"+string(filepath.Separator)) {
+		return "", false
+	}
+	return filePath, true
+}
+
+// removePhoto deletes a photo saved by savePhoto.
+func removePhoto(photoPath string) error {
+	filePath, ok := PhotoFile(photoPath)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"debtNote/models"
+	"debtNote/repository"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Retention purges closed debts that are past the shop's retention policy,
+// saving them to a compressed archive first.
+type Retention struct {
+	Debts    repository.DebtRepository
+	Settings repository.SettingsRepository
+}
+
+// ArchiveDir is where purged debts are saved, relative to the working directory like uploads/.
+const ArchiveDir = "archive"
+
+// archiveFile is the content of an archive: the policy that applied and the rows it removed.
+type archiveFile struct {
+	PurgedAt      time.Time             `json:"purged_at"`
+	DeletedBefore *time.Time            `json:"deleted_before,omitempty"`
+	PaidBefore    *time.Time            `json:"paid_before,omitempty"`
+	Debts         []models.ArchivedDebt `json:"debts"`
+}
+
+// Policy returns the retention policy configured in settings.
+func (s *Retention) Policy(ctx context.Context) (repository.RetentionPolicy, error) {
+	return repository.LoadRetentionPolicy(ctx, s.Settings)
+}
+
+// Run purges the debts past policy as of now. With dryRun it only reports what would be purged.
+func (s *Retention) Run(ctx context.Context, policy repository.RetentionPolicy, now time.Time, dryRun bool) (models.PurgeReport, error) {
+	report := models.PurgeReport{DryRun: dryRun}
+
+	deletedBefore, paidBefore := policy.Cutoffs(now.UTC())
+	if !deletedBefore.IsZero() {
+		report.DeletedBefore = &deletedBefore
+	}
+	if !paidBefore.IsZero() {
+		report.PaidBefore = &paidBefore
+	}
+	if !policy.Enabled() {
+		return report, nil
+	}
+
+	candidates, err := s.Debts.FindPurgeCandidates(ctx, deletedBefore, paidBefore)
+	if err != nil {
+		return report, err
+	}
+
+	ids := make([]int64, len(candidates))
+	for i, d := range candidates {
+		ids[i] = d.Debt.ID
+		report.Payments += len(d.Payments)
+		if d.Debt.Status == models.StatusDeleted {
+			report.DeletedDebts++
+		} else {
+			report.PaidDebts++
+		}
+	}
+	if dryRun || len(candidates) == 0 {
+		return report, nil
+	}
+
+	archive, err := writeArchive(archiveFile{
+		PurgedAt:      now.UTC(),
+		DeletedBefore: report.DeletedBefore,
+		PaidBefore:    report.PaidBefore,
+		Debts:         candidates,
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to archive debts: %w", err)
+	}
+	report.Archive = archive
+
+	return report, s.Debts.PurgeDebts(ctx, ids, deletedBefore, paidBefore, archive)
+}
+
+// writeArchive saves the purged rows as gzipped JSON and returns the file's path.
+func writeArchive(content archiveFile) (string, error) {
+	if err := os.MkdirAll(ArchiveDir, 0755); err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(ArchiveDir, "debts-"+content.PurgedAt.Format("2006-01-02-150405.000")+".json.gz")
+	if err := encodeArchive(name, content); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(name), nil
+}
+
+// encodeArchive writes content to the new file name as gzipped JSON.
+func encodeArchive(name string, content archiveFile) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(f)
+	encodeErr := json.NewEncoder(zw).Encode(content)
+	closeErr := zw.Close()
+	fileErr := f.Close()
+	for _, err := range []error{encodeErr, closeErr, fileErr} {
+		if err != nil {
+			os.Remove(name) // A broken archive must not look like a good one
+			return err
+		}
+	}
+	return nil
+}
+
+// readArchive reads an archive written by writeArchive.
+func readArchive(name string) (archiveFile, error) {
+	var content archiveFile
+	f, err := os.Open(name)
+	if err != nil {
+		return content, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return content, err
+	}
+	defer zr.Close()
+	err = json.NewDecoder(zr).Decode(&content)
+	return content, err
+}
+
+// scrubArchives erases the comments on a client's purged debts and their payments from the
+// archives, as AnonymizeClient does for the debts still in the database, and returns the IDs of
+// the client's archived debts. Archives are rewritten through a temporary file, so one is never
+// left half written.
+func scrubArchives(clientID int64) ([]int64, error) {
+	names, err := filepath.Glob(filepath.Join(ArchiveDir, "debts-*.json.gz"))
+	if err != nil {
+		return nil, err
+	}
+
+	var debtIDs []int64
+	for _, name := range names {
+		content, err := readArchive(name)
+		if err != nil {
+			return debtIDs, fmt.Errorf("%s: %w", name, err)
+		}
+
+		scrubbed := false
+		for i := range content.Debts {
+			d := &content.Debts[i]
+			if d.Debt.ClientID != clientID {
+				continue
+			}
+			debtIDs = append(debtIDs, d.Debt.ID)
+			scrubbed = scrubbed || d.Debt.Comment != "" || d.Debt.DeleteComment != ""
+			d.Debt.Comment, d.Debt.DeleteComment = "", ""
+			for j := range d.Payments {
+				scrubbed = scrubbed || d.Payments[j].Comment != ""
+				d.Payments[j].Comment = ""
+			}
+		}
+		if !scrubbed {
+			continue
+		}
+
+		temp := name + ".tmp"
+		os.Remove(temp) // Left by an earlier run that failed
+		if err := encodeArchive(temp, content); err != nil {
+			return debtIDs, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := os.Rename(temp, name); err != nil {
+			os.Remove(temp)
+			return debtIDs, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return debtIDs, nil
+}
+
+// Schedule runs the policy configured in settings now and then every interval, logging what it purged.
+// It is meant to be started in its own goroutine and returns when ctx is done.
+func (s *Retention) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		policy, err := s.Policy(ctx)
+		if err != nil {
+			log.Printf("Retention: failed to load policy: %v", err)
+		} else if policy.Enabled() {
+			report, err := s.Run(ctx, policy, time.Now(), false)
+			if err != nil {
+				log.Printf("Retention: purge failed: %v", err)
+			} else if report.Archive != "" {
+				log.Printf("Retention: purged %d deleted and %d paid debts, archived to %s", report.DeletedDebts, report.PaidDebts, report.Archive)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
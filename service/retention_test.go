@@ -0,0 +1,80 @@
+package service
+
+import (
+	"debtNote/models"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScrubArchives(t *testing.T) {
+	t.Chdir(t.TempDir())
+	purged := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	archive, err := writeArchive(archiveFile{PurgedAt: purged, Debts: []models.ArchivedDebt{
+		{Debt: models.Debt{ID: 1, ClientID: 7, Comment: "bread", DeleteComment: "typo"}},
+		{Debt: models.Debt{ID: 2, ClientID: 8, Comment: "flour"}, Payments: []models.DebtPayment{{Comment: "cash"}}},
+		{Debt: models.Debt{ID: 3, ClientID: 7, Comment: "milk"}, Payments: []models.DebtPayment{{Comment: "cash"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An archive without the client's debts is left as it is
+	untouched, err := writeArchive(archiveFile{PurgedAt: purged.Add(time.Hour), Debts: []models.ArchivedDebt{
+		{Debt: models.Debt{ID: 4, ClientID: 8, Comment: "sugar"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(untouched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := scrubArchives(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("scrubArchives = %v; want debts 1 and 3", ids)
+	}
+
+	content, err := readArchive(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comments := func(d models.ArchivedDebt) []string {
+		c := []string{d.Debt.Comment, d.Debt.DeleteComment}
+		for _, p := range d.Payments {
+			c = append(c, p.Comment)
+		}
+		return c
+	}
+	want := [][]string{{"", ""}, {"flour", "", "cash"}, {"", "", ""}}
+	for i, d := range content.Debts {
+		if got := comments(d); !slices.Equal(got, want[i]) {
+			t.Errorf("debt %d comments = %q; want %q", d.Debt.ID, got, want[i])
+		}
+	}
+	if !content.PurgedAt.Equal(purged) {
+		t.Errorf("purged_at = %v; want it kept", content.PurgedAt)
+	}
+	if after, err := os.Stat(untouched); err != nil || !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("archive without the client was rewritten")
+	}
+	if _, err := os.Stat(archive + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	// A second run finds the debts but has nothing more to erase
+	if ids, err := scrubArchives(7); err != nil || !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("second scrubArchives = %v, %v", ids, err)
+	}
+}
+
+func TestScrubArchivesNone(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if ids, err := scrubArchives(7); err != nil || len(ids) != 0 {
+		t.Errorf("scrubArchives without archives = %v, %v", ids, err)
+	}
+}
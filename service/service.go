@@ -0,0 +1,58 @@
+// Package service holds the shop's business rules: it validates requests and runs the workflows that
+// change debts and clients on top of the repositories. The HTTP handlers and the command line tools
+// both go through it, so a rule is enforced the same way wherever a change comes from.
+package service
+
+import (
+	"debtNote/i18n"
+	"debtNote/models"
+	"debtNote/repository"
+)
+
+// ValidationError reports a request that breaks a rule. Field names the request field at fault,
+// as it is called in the API.
+type ValidationError struct {
+	Field   string
+	Message *i18n.Message
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message.Error()
+}
+
+// Unwrap gives the message, so it can be translated.
+func (e *ValidationError) Unwrap() error {
+	return e.Message
+}
+
+// invalid returns a ValidationError for field with catalog message id.
+func invalid(field, id string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: i18n.New(id, args...)}
+}
+
+// CreditRefusedError is returned when a client's credit check refuses a new debt
+// and the owner did not override it.
+type CreditRefusedError struct {
+	Credit models.CreditCheck
+}
+
+// Error explains the refusal; the catalog has a message for each models.CreditReason... code.
+func (e *CreditRefusedError) Error() string {
+	return i18n.T(i18n.Default, e.Credit.Reason)
+}
+
+// Errors returned by the services. Callers compare them with errors.Is.
+var (
+	// The debt or client does not exist
+	ErrDebtNotFound   = i18n.New("debt_not_found")
+	ErrClientNotFound = i18n.New("client_not_found")
+
+	// The change does not fit the current state
+	ErrDebtNotActive  = i18n.New("debt_not_active")
+	ErrDebtNotDeleted = i18n.New("debt_not_deleted")
+	ErrDebtChanged    = repository.ErrDebtChanged
+	ErrClientHasDebts = repository.ErrClientHasDebts
+
+	// Only the owner may do this
+	ErrOwnerPinRequired = i18n.New("owner_pin_required")
+)
@@ -15,6 +15,11 @@ document.addEventListener('DOMContentLoaded', () => {
     let currentDebtToPay = null;
     let currentDebtFullAmount = 0;
 
+    // Escapes text typed by the operator before it is put into innerHTML
+    const escapeHtml = (text) => String(text ?? '').replace(/[&<>"']/g, c => ({
+        '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'
+    })[c]);
+
     // --- Router ---
     const routes = {
         '/': 'home-page',
@@ -24,6 +29,14 @@ document.addEventListener('DOMContentLoaded', () => {
     };
 
     const render = (path) => {
+        // Deep link to a client, e.g. /clients/42: the client list with their details open
+        const clientMatch = path.match(/^\/clients\/(\d+)$/);
+        if (clientMatch) {
+            render('/clients');
+            openClientById(clientMatch[1]);
+            return;
+        }
+
         const templateId = routes[path] || 'home-page';
         const template = document.getElementById(templateId);
         if (template) {
@@ -66,6 +79,13 @@ document.addEventListener('DOMContentLoaded', () => {
                 }
             }
         }
+
+        // Handle Restore Debt Button
+        if (e.target.classList.contains('restore-debt-btn')) {
+            if (confirm('Бул карызды корзинадан калыбына келтиресизби?')) {
+                restoreDebt(e.target.dataset.debtId);
+            }
+        }
     });
 
     // --- Helper: Open Image in New Window ---
@@ -184,6 +204,7 @@ document.addEventListener('DOMContentLoaded', () => {
         const phoneInput = document.getElementById('phone');
         const addressInput = document.getElementById('address');
         const clientSuggestions = document.getElementById('client-suggestions');
+        const duplicateWarning = document.getElementById('duplicate-warning');
         const photoElement = document.getElementById('photo');
         const photoDataElement = document.getElementById('photo-data');
         const webcamElement = document.getElementById('webcam');
@@ -240,6 +261,11 @@ document.addEventListener('DOMContentLoaded', () => {
                                 reputationBadge = '<span class="px-2 py-0.5 text-xs font-bold text-gray-600 bg-gray-200 rounded">Жаңы</span>';
                         }
 
+                        // Stop-credit flag
+                        const blockedNote = client.blocked
+                            ? `<div class="text-xs font-bold text-red-600">Карыз берилбейт: ${escapeHtml(client.blocked_reason || '-')}</div>`
+                            : '';
+
                         item.innerHTML = `
                             <img src="${client.photo_data || 'https://via.placeholder.com/40'}" class="w-10 h-10 rounded-full object-cover zoomable-image">
                             <div class="flex-grow ml-2">
@@ -248,6 +274,7 @@ document.addEventListener('DOMContentLoaded', () => {
                                     <div class="flex items-center">${debtIndicator}${reputationBadge}</div>
                                 </div>
                                 <div class="text-sm text-gray-500">${client.phone}</div>
+                                ${blockedNote}
                             </div>
                         `;
                         item.addEventListener('click', (e) => {
@@ -271,6 +298,47 @@ document.addEventListener('DOMContentLoaded', () => {
             }, 200);
         });
 
+        // Warn about existing clients that look like the new one (a typo in the name or number)
+        phoneInput.addEventListener('change', async () => {
+            duplicateWarning.classList.add('hidden');
+            if (isExistingClient || !phoneInput.value.trim()) {
+                return;
+            }
+            const params = new URLSearchParams({ fullname: fullnameInput.value, phone: phoneInput.value });
+            const response = await fetch(`/api/clients/duplicates?${params}`);
+            if (!response.ok) {
+                return;
+            }
+            const candidates = await response.json();
+            if (!candidates || candidates.length === 0) {
+                return;
+            }
+
+            duplicateWarning.innerHTML = '<div class="font-bold text-yellow-800 mb-1">Окшош клиенттер бар. Ушул кишиби?</div>';
+            candidates.forEach(candidate => {
+                const reasons = candidate.reasons.map(reason => {
+                    const field = reason.field === 'phone' ? 'номер' : 'аты';
+                    return reason.distance === 0 ? `${field} бирдей` : `${field} окшош`;
+                }).join(', ');
+
+                const item = document.createElement('div');
+                item.className = 'p-1 hover:bg-yellow-100 cursor-pointer flex items-center space-x-2';
+                item.innerHTML = `
+                    <img src="${escapeHtml(candidate.photo_data || 'https://via.placeholder.com/40')}" class="w-8 h-8 rounded-full object-cover">
+                    <div class="flex-grow">
+                        <div class="font-bold">${escapeHtml(candidate.fullname)} <span class="font-normal text-gray-500">${escapeHtml(candidate.phone)}</span></div>
+                        <div class="text-xs text-gray-600">${reasons}</div>
+                    </div>
+                `;
+                item.addEventListener('click', () => {
+                    selectClient(candidate);
+                    duplicateWarning.classList.add('hidden');
+                });
+                duplicateWarning.appendChild(item);
+            });
+            duplicateWarning.classList.remove('hidden');
+        });
+
         function selectClient(client) {
             fullnameInput.value = client.fullname;
             phoneInput.value = client.phone;
@@ -306,6 +374,7 @@ document.addEventListener('DOMContentLoaded', () => {
         fullnameInput.addEventListener('focus', () => {
             if (phoneInput.readOnly) {
                 addDebtForm.reset();
+                duplicateWarning.classList.add('hidden');
                 phoneInput.readOnly = false;
                 addressInput.readOnly = false;
                 
@@ -393,7 +462,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 return;
             }
 
-            const response = await fetch('/api/debts/add', {
+            const response = await fetch('/api/debts', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(data),
@@ -492,10 +561,10 @@ document.addEventListener('DOMContentLoaded', () => {
 
     async function deleteDebt(debtId, comment) {
         try {
-            const response = await fetch('/api/debts/delete', {
-                method: 'POST',
+            const response = await fetch(`/api/debts/${debtId}`, {
+                method: 'DELETE',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ debt_id: parseInt(debtId), comment: comment }),
+                body: JSON.stringify({ comment: comment }),
             });
 
             if (response.ok) {
@@ -511,14 +580,40 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     }
 
+    async function restoreDebt(debtId) {
+        try {
+            const response = await fetch(`/api/debts/${debtId}/restore`, { method: 'POST' });
+
+            if (response.ok) {
+                alert('Карыз калыбына келтирилди.');
+                loadDeletedDebts(1);
+            } else {
+                const error = await response.json();
+                alert(`Ката: ${error.message || 'Калыбына келтирүүдө ката кетти.'}`);
+            }
+        } catch (error) {
+            console.error('Restore error:', error);
+            alert('Калыбына келтирүүдө ката кетти.');
+        }
+    }
+
     // --- Clients Page Logic ---
+    const reputationLabels = {
+        good: 'Жакшы',
+        bad: 'Начар',
+        untrusted: 'Ишенич жок',
+        none: 'Жаңы',
+    };
+
     function initClientsPage() {
         const searchInput = document.getElementById('search-clients');
         const dateFilter = document.getElementById('filter-date-clients');
+        const sortSelect = document.getElementById('sort-clients');
         const limitSelect = document.getElementById('limit-clients');
         
         searchInput.addEventListener('input', () => loadClients(1));
         dateFilter.addEventListener('change', () => loadClients(1));
+        sortSelect.addEventListener('change', () => loadClients(1));
         limitSelect.addEventListener('change', () => loadClients(1));
 
         loadClients(1);
@@ -527,16 +622,19 @@ document.addEventListener('DOMContentLoaded', () => {
     async function loadClients(page) {
         const searchInput = document.getElementById('search-clients');
         const dateFilter = document.getElementById('filter-date-clients');
+        const sortSelect = document.getElementById('sort-clients');
         const limitSelect = document.getElementById('limit-clients');
         
         const search = searchInput ? searchInput.value : '';
         const date = dateFilter ? dateFilter.value : '';
+        const sortBy = sortSelect ? sortSelect.value : '';
         const limit = limitSelect ? limitSelect.value : 200;
 
         const container = document.getElementById('clients-container');
         let url = `/api/clients?page=${page}&limit=${limit}`;
         if (search) url += `&search=${encodeURIComponent(search)}`;
         if (date) url += `&date=${date}`;
+        if (sortBy) url += `&sort_by=${sortBy}`;
 
         const response = await fetch(url);
         const result = await response.json();
@@ -552,6 +650,10 @@ document.addEventListener('DOMContentLoaded', () => {
                         <th class="py-2 px-4 border-b text-left">Аты-жөнү</th>
                         <th class="py-2 px-4 border-b text-left">Телефон</th>
                         <th class="py-2 px-4 border-b text-left">Дареги</th>
+                        <th class="py-2 px-4 border-b text-left">Карызы</th>
+                        <th class="py-2 px-4 border-b text-left">Акыркы төлөм</th>
+                        <th class="py-2 px-4 border-b text-left">Эң эски карыз</th>
+                        <th class="py-2 px-4 border-b text-left">Репутация</th>
                         <th class="py-2 px-4 border-b text-left">Кошулган күнү</th>
                     </tr>
                 </thead>
@@ -575,6 +677,13 @@ document.addEventListener('DOMContentLoaded', () => {
                     <td class="py-2 px-4 font-semibold text-blue-600">${client.fullname}</td>
                     <td class="py-2 px-4">${client.phone}</td>
                     <td class="py-2 px-4">${client.address}</td>
+                    <td class="py-2 px-4 ${client.outstanding > 0 ? 'font-bold text-red-600' : ''}">
+                        ${client.outstanding.toFixed(2)}
+                        <div class="text-xs text-gray-500 font-normal">${client.active_debts} карыз</div>
+                    </td>
+                    <td class="py-2 px-4">${client.last_payment_at ? new Date(client.last_payment_at).toLocaleDateString() : '-'}</td>
+                    <td class="py-2 px-4">${client.oldest_active_debt_at ? new Date(client.oldest_active_debt_at).toLocaleDateString() : '-'}</td>
+                    <td class="py-2 px-4">${reputationLabels[client.reputation] || reputationLabels.none} <span class="text-xs text-gray-500">(${Math.round(client.reputation_score)})</span></td>
                     <td class="py-2 px-4">${new Date(client.created_at).toLocaleDateString()}</td>
                 `;
                 
@@ -588,13 +697,16 @@ document.addEventListener('DOMContentLoaded', () => {
                 tableBody.appendChild(row);
             });
         } else {
-            tableBody.innerHTML = '<tr><td colspan="6" class="text-center py-4">Клиенттер жок.</td></tr>';
+            tableBody.innerHTML = '<tr><td colspan="10" class="text-center py-4">Клиенттер жок.</td></tr>';
         }
         createPagination('pagination-clients', page, total, limit, loadClients);
     }
 
     // --- Client Details Modal Logic ---
+    let detailsClientId = null;
+
     async function openClientDetails(client) {
+        detailsClientId = client.id;
         document.getElementById('details-client-name').textContent = client.fullname;
         
         // Load Active Debts
@@ -611,6 +723,18 @@ document.addEventListener('DOMContentLoaded', () => {
         clientDetailsModal.classList.add('flex');
     }
 
+    async function openClientById(clientId) {
+        try {
+            const response = await fetch(`/api/clients/${clientId}`);
+            if (!response.ok) {
+                throw new Error((await response.json()).message);
+            }
+            openClientDetails(await response.json());
+        } catch (error) {
+            alert(`Ката: ${error.message || 'Клиентти ачууда ката кетти.'}`);
+        }
+    }
+
     function renderClientDebtsTable(containerId, debts, isActive) {
         const container = document.getElementById(containerId);
         if (!debts || debts.length === 0) {
@@ -650,7 +774,7 @@ document.addEventListener('DOMContentLoaded', () => {
 
     // Expose function to global scope for onclick handler
     window.openPaymentHistory = async function(debtID) {
-        const response = await fetch(`/api/debts/payments?debt_id=${debtID}`);
+        const response = await fetch(`/api/debts/${debtID}/payments`);
         const payments = await response.json();
         
         const container = document.getElementById('payment-history-content');
@@ -696,6 +820,41 @@ document.addEventListener('DOMContentLoaded', () => {
         clientDetailsModal.classList.remove('flex');
     });
 
+    async function removeClient(mode, question) {
+        if (!detailsClientId || !confirm(question)) {
+            return;
+        }
+        try {
+            const response = await fetch(`/api/clients/${detailsClientId}`, {
+                method: 'DELETE',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ mode: mode }),
+            });
+            if (!response.ok) {
+                throw new Error((await response.json()).message);
+            }
+            clientDetailsModal.classList.add('hidden');
+            clientDetailsModal.classList.remove('flex');
+            loadClients(1);
+        } catch (error) {
+            alert(`Ката: ${error.message || 'Өчүрүүдө ката кетти.'}`);
+        }
+    }
+
+    document.getElementById('export-client').addEventListener('click', () => {
+        if (detailsClientId) {
+            window.location.href = `/api/clients/${detailsClientId}/export`;
+        }
+    });
+
+    document.getElementById('delete-client').addEventListener('click', () => {
+        removeClient('delete', 'Бул клиентти толук өчүрөсүзбү?');
+    });
+
+    document.getElementById('anonymize-client').addEventListener('click', () => {
+        removeClient('anonymize', 'Клиенттин аты, телефону, дареги жана сүрөтү биротоло өчүрүлөт. Карыздары калат. Улантасызбы?');
+    });
+
     // --- History Page Logic ---
     function initHistoryPage() {
         const searchInput = document.getElementById('search-history');
@@ -806,6 +965,7 @@ document.addEventListener('DOMContentLoaded', () => {
                         <th class="py-2 px-4">Алынган күнү</th>
                         <th class="py-2 px-4">Өчүрүлгөн күнү</th>
                         <th class="py-2 px-4">Себеби</th>
+                        <th class="py-2 px-4">Аракет</th>
                     </tr>
                 </thead>
                 <tbody id="deleted-table-body"></tbody>
@@ -824,10 +984,13 @@ document.addEventListener('DOMContentLoaded', () => {
                         <td class="py-2 px-4">${new Date(debt.created_at).toLocaleDateString()}</td>
                         <td class="py-2 px-4 text-red-600">${debt.deleted_at ? new Date(debt.deleted_at).toLocaleDateString() : '-'}</td>
                         <td class="py-2 px-4 text-sm text-gray-600 italic">${debt.delete_comment || '-'}</td>
+                        <td class="py-2 px-4">
+                            <button data-debt-id="${debt.debt_id}" class="restore-debt-btn px-3 py-1 bg-blue-500 text-white rounded text-sm hover:bg-blue-600">Калыбына келтирүү</button>
+                        </td>
                     </tr>`;
             });
         } else {
-            tableBody.innerHTML = '<tr><td colspan="5" class="text-center py-4">Корзина бош.</td></tr>';
+            tableBody.innerHTML = '<tr><td colspan="6" class="text-center py-4">Корзина бош.</td></tr>';
         }
         createPagination('pagination-deleted', page, total, limit, loadDeletedDebts);
     }
@@ -880,11 +1043,10 @@ document.addEventListener('DOMContentLoaded', () => {
             return;
         }
 
-        const response = await fetch('/api/debts/pay', {
+        const response = await fetch(`/api/debts/${currentDebtToPay}/payments`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({ 
-                debt_id: parseInt(currentDebtToPay), 
                 paid_amount: paidAmount,
                 comment: comment,
                 rating: rating 
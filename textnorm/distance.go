@@ -0,0 +1,37 @@
+package textnorm
+
+import (
+	"sort"
+	"strings"
+)
+
+// Distance is the Levenshtein edit distance between a and b, counted in letters (runes).
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// NameKey is the form names are compared in: the skeleton of each word, in alphabetical order,
+// so "Асан Уулу" and "Uulu Asan" have the same key.
+func NameKey(name string) string {
+	words := strings.Fields(Skeleton(name))
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
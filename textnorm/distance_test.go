@@ -0,0 +1,48 @@
+package textnorm
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"aidar", "aidar", 0},
+		{"", "asan", 4},
+		{"asan", "", 4},
+		{"asan", "hasan", 1},  // Insertion
+		{"aidar", "aydar", 1}, // Substitution
+		{"asan", "asn", 1},    // Deletion
+		{"kitten", "sitting", 3},
+		// Counted in letters, not bytes
+		{"Асан", "Асын", 1},
+		{"Өмүр", "Омур", 2},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d; want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"Асан Уулу", "Uulu Asan"},
+		{"  Айдар   Жумабеков ", "zhumabekov aidar"},
+		{"Өмүрбек", "OMURBEK"},
+	}
+	for _, tt := range tests {
+		if ka, kb := NameKey(tt.a), NameKey(tt.b); ka != kb {
+			t.Errorf("NameKey(%q) = %q, NameKey(%q) = %q; want them equal", tt.a, ka, tt.b, kb)
+		}
+	}
+	if got := NameKey("Асан Уулу"); got != "asan uulu" {
+		t.Errorf("NameKey(%q) = %q; want %q", "Асан Уулу", got, "asan uulu")
+	}
+}
@@ -0,0 +1,120 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillicToLatin spells Kyrgyz and Russian letters in Latin, the way names are usually typed.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'ң': "ng",
+	'о': "o", 'ө': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ү': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// latinSkeleton merges Latin spellings that stand for the same sound, so every transliteration
+// of a name ends up the same: "Zhyldyz", "Jyldyz" and "Жылдыз" all become "jildiz".
+// Longer sequences come first so "shch" wins over "sh" and "ch" is not split.
+var latinSkeleton = strings.NewReplacer(
+	"shch", "sh",
+	"zh", "j",
+	"kh", "h",
+	"ch", "ch",
+	"sh", "sh",
+	"ng", "n",
+	"y", "i",
+	"w", "v",
+	"q", "k",
+	"x", "ks",
+)
+
+// Skeleton reduces a name to a lossy Latin form for matching across scripts:
+// folded, transliterated from Cyrillic and with alternative spellings merged.
+// "Айдар", "Aidar" and "AYDAR" share the skeleton "aidar"; "Өмүрбек" and "Omurbek" share "omurbek".
+func Skeleton(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range Fold(s) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return latinSkeleton.Replace(b.String())
+}
+
+// Keys of the Russian ЙЦУКЕН layout (which the Kyrgyz layout builds on) and the Latin keys in the same places.
+const (
+	latinKeys    = "`qwertyuiop[]asdfghjkl;'zxcvbnm,."
+	cyrillicKeys = "ёйцукенгшщзхъфывапролджэячсмитьбю"
+)
+
+// The Kyrgyz letters are typed with AltGr on the keys of the letters they come from: ң on н, ө on о
+// and ү on у. Retyped on the Latin layout they give those keys' letters; the other way round needs
+// nothing, as "Jveh,tr" comes back as "омурбек", which has the same skeleton as "Өмүрбек".
+const (
+	kyrgyzKeys      = "ңөү"
+	kyrgyzLatinKeys = "yje"
+)
+
+var (
+	latinToCyrillicKeys = keyMap(latinKeys, cyrillicKeys)
+	cyrillicToLatinKeys = keyMap(cyrillicKeys+kyrgyzKeys, latinKeys+kyrgyzLatinKeys)
+)
+
+func keyMap(from, to string) map[rune]rune {
+	fromRunes, toRunes := []rune(from), []rune(to)
+	m := make(map[rune]rune, len(fromRunes))
+	for i, r := range fromRunes {
+		m[r] = toRunes[i]
+	}
+	return m
+}
+
+// swapLayout retypes s as if the other keyboard layout had been active.
+func swapLayout(s string, keys map[rune]rune) string {
+	return strings.Map(func(r rune) rune {
+		if swapped, ok := keys[unicode.ToLower(r)]; ok {
+			return swapped
+		}
+		return r
+	}, s)
+}
+
+// SearchVariants returns the distinct skeletons a search query may stand for: the query itself,
+// and the query retyped on the other keyboard layout ("Fqlfh" was meant to be "Айдар").
+// Match them against Skeleton of the stored name.
+func SearchVariants(query string) []string {
+	candidates := []string{query}
+
+	hasLatin, hasCyrillic := false, false
+	for _, r := range query {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			hasLatin = true
+		case unicode.Is(unicode.Cyrillic, r):
+			hasCyrillic = true
+		}
+	}
+	if hasLatin {
+		candidates = append(candidates, swapLayout(query, latinToCyrillicKeys))
+	}
+	if hasCyrillic {
+		candidates = append(candidates, swapLayout(query, cyrillicToLatinKeys))
+	}
+
+	var variants []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		skeleton := strings.TrimSpace(Skeleton(c))
+		if skeleton == "" || seen[skeleton] {
+			continue
+		}
+		seen[skeleton] = true
+		variants = append(variants, skeleton)
+	}
+	return variants
+}
@@ -0,0 +1,85 @@
+package textnorm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"Айдар", "Aidar", "AYDAR", "Aydar"}, "aidar"},
+		{[]string{"Өмүрбек", "Omurbek", "ОМУРБЕК"}, "omurbek"},
+		{[]string{"Жылдыз", "Zhyldyz", "Jyldyz"}, "jildiz"},
+		{[]string{"Хасан", "Khasan", "Hasan"}, "hasan"},
+		{[]string{"Щукин", "Shchukin", "Shukin"}, "shukin"},
+		{[]string{"Таң", "Tang", "Tan"}, "tan"},
+	}
+	for _, tt := range tests {
+		for _, name := range tt.names {
+			if got := Skeleton(name); got != tt.want {
+				t.Errorf("Skeleton(%q) = %q; want %q", name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSwapLayout(t *testing.T) {
+	tests := []struct {
+		s    string
+		keys map[rune]rune
+		want string
+	}{
+		{"Fqlfh", latinToCyrillicKeys, "айдар"},
+		{"Фшвфк", cyrillicToLatinKeys, "aidar"},
+		{"ёхъ", cyrillicToLatinKeys, "`[]"},
+		// The Kyrgyz letters sit on the keys of н, о and у
+		{"ңөү", cyrillicToLatinKeys, "yje"},
+		{"ҢӨҮ", cyrillicToLatinKeys, "yje"},
+		{"Нөтө", cyrillicToLatinKeys, "yjnj"},
+		{"Aidar 42", cyrillicToLatinKeys, "Aidar 42"},
+	}
+	for _, tt := range tests {
+		if got := swapLayout(tt.s, tt.keys); got != tt.want {
+			t.Errorf("swapLayout(%q) = %q; want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSearchVariants(t *testing.T) {
+	tests := []struct {
+		query string
+		meant []string // Names whose skeletons must be among the variants
+	}{
+		{"Айдар", []string{"Айдар"}},
+		{"Fqlfh", []string{"Fqlfh", "Айдар"}},
+		{"Фшвфк", []string{"Фшвфк", "Aidar"}},
+		// "Өмүрбек" typed with the Latin layout still on
+		{"Jveh,tr", []string{"Өмүрбек"}},
+		// Latin names typed with the Kyrgyz layout on, AltGr and all
+		{"ңутпф", []string{"Yenga"}},
+		{"өштп", []string{"Jing"}},
+	}
+	for _, tt := range tests {
+		got := SearchVariants(tt.query)
+		for _, name := range tt.meant {
+			if want := Skeleton(name); !slices.Contains(got, want) {
+				t.Errorf("SearchVariants(%q) = %q; want it to contain %q", tt.query, got, want)
+			}
+		}
+	}
+}
+
+func TestSearchVariantsDistinct(t *testing.T) {
+	if got := SearchVariants("aidar"); len(got) != 2 {
+		t.Errorf("SearchVariants(%q) = %q; want the query and its retyped form", "aidar", got)
+	}
+	if got := SearchVariants("  "); len(got) != 0 {
+		t.Errorf("SearchVariants(%q) = %q; want none", "  ", got)
+	}
+	if got := SearchVariants("42"); !slices.Equal(got, []string{"42"}) {
+		t.Errorf("SearchVariants(%q) = %q; want only the query", "42", got)
+	}
+}